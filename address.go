@@ -0,0 +1,174 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// phoneRule formats the national numbers of a country.
+type phoneRule struct {
+	// code is the country calling code.
+	code string
+
+	// trunk is the prefix of national numbers, like "0".
+	trunk string
+
+	// groups are the lengths of the digit groups separated by spaces.
+	// The remaining digits form the last group.
+	groups []int
+}
+
+// phoneRules are the phone number rules by ISO 3166-1 alpha-2 country code.
+var phoneRules = map[string]phoneRule{
+	"AT": {code: "43", trunk: "0"},
+	"AU": {code: "61", trunk: "0", groups: []int{1, 4}},
+	"BE": {code: "32", trunk: "0"},
+	"CA": {code: "1", groups: []int{3, 3}},
+	"CH": {code: "41", trunk: "0", groups: []int{2, 3, 2}},
+	"DE": {code: "49", trunk: "0"},
+	"ES": {code: "34", groups: []int{3, 3}},
+	"FR": {code: "33", trunk: "0", groups: []int{1, 2, 2, 2}},
+	"GB": {code: "44", trunk: "0"},
+	"IT": {code: "39"},
+	"NL": {code: "31", trunk: "0"},
+	"US": {code: "1", groups: []int{3, 3}},
+}
+
+// FormatPhone formats a phone number in E.164 format, like "+14155552671",
+// in the national format of the country, like "(415) 555-2671" for "US".
+// Numbers of other countries are kept in E.164 format and numbers
+// without a leading plus sign are kept as they are.
+// The country is an ISO 3166-1 alpha-2 code.
+func FormatPhone(number, country string) (string, error) {
+	rule, ok := phoneRules[strings.ToUpper(country)]
+	if !ok {
+		return "", fmt.Errorf("unsupported phone country '%s'", country)
+	}
+
+	number = strings.TrimSpace(number)
+	if !strings.HasPrefix(number, "+") {
+		return number, nil
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' {
+			return -1
+		}
+		return r
+	}, number[1:])
+	if digits == "" || strings.Trim(digits, "0123456789") != "" || len(digits) > 15 {
+		return "", fmt.Errorf("invalid phone number: '%s'", number)
+	}
+
+	national, ok := strings.CutPrefix(digits, rule.code)
+	if !ok {
+		return "+" + digits, nil
+	}
+
+	// North American numbers are written like "(415) 555-2671".
+	if rule.code == "1" {
+		if len(national) != 10 {
+			return "", fmt.Errorf("invalid phone number: '%s'", number)
+		}
+		return fmt.Sprintf("(%s) %s-%s", national[:3], national[3:6], national[6:]), nil
+	}
+
+	national = rule.trunk + national
+	var parts []string
+	for i, n := range rule.groups {
+		if i == 0 {
+			n += len(rule.trunk)
+		}
+		if len(national) <= n {
+			break
+		}
+		parts = append(parts, national[:n])
+		national = national[n:]
+	}
+	return strings.Join(append(parts, national), " "), nil
+}
+
+// postalCodeLengths are the number of digits of numeric postal codes
+// by ISO 3166-1 alpha-2 country code.
+var postalCodeLengths = map[string]int{
+	"AT": 4, "AU": 4, "BE": 4, "CH": 4, "DE": 5, "ES": 5, "FR": 5, "IT": 5,
+}
+
+// NormalizePostalCode normalizes the postal code to the format of the
+// country, like "k1a0b1" to "K1A 0B1" for "CA" or "123456789" to
+// "12345-6789" for "US". Invalid postal codes fail.
+// The country is an ISO 3166-1 alpha-2 code.
+func NormalizePostalCode(code, country string) (string, error) {
+	country = strings.ToUpper(country)
+	s := strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	invalid := fmt.Errorf("invalid postal code: '%s'", code)
+
+	isDigits := func(s string) bool {
+		return s != "" && strings.Trim(s, "0123456789") == ""
+	}
+	isLetters := func(s string) bool {
+		return s != "" && strings.Trim(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == ""
+	}
+
+	switch country {
+	case "US":
+		s = strings.ReplaceAll(s, "-", "")
+		switch {
+		case len(s) == 5 && isDigits(s):
+			return s, nil
+		case len(s) == 9 && isDigits(s):
+			return s[:5] + "-" + s[5:], nil
+		}
+		return "", invalid
+
+	case "CA":
+		if len(s) != 6 {
+			return "", invalid
+		}
+		for i := 0; i < 6; i++ {
+			if (i%2 == 0 && !isLetters(s[i:i+1])) || (i%2 == 1 && !isDigits(s[i:i+1])) {
+				return "", invalid
+			}
+		}
+		return s[:3] + " " + s[3:], nil
+
+	case "GB":
+		// The inward code consists of a digit and two letters.
+		if len(s) < 5 || len(s) > 7 || !isDigits(s[len(s)-3:len(s)-2]) || !isLetters(s[len(s)-2:]) {
+			return "", invalid
+		}
+		return s[:len(s)-3] + " " + s[len(s)-3:], nil
+
+	case "NL":
+		if len(s) != 6 || !isDigits(s[:4]) || !isLetters(s[4:]) {
+			return "", invalid
+		}
+		return s[:4] + " " + s[4:], nil
+	}
+
+	n, ok := postalCodeLengths[country]
+	if !ok {
+		return "", fmt.Errorf("unsupported postal code country '%s'", country)
+	}
+	if len(s) != n || !isDigits(s) {
+		return "", invalid
+	}
+	return s, nil
+}
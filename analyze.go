@@ -0,0 +1,257 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Info describes a PDF file.
+type Info struct {
+	// Version is the PDF version of the file header, like "1.7".
+	Version string
+
+	// Encrypted is true if the file is encrypted.
+	Encrypted bool
+
+	// AcroForm is true if the file contains an interactive form.
+	AcroForm bool
+
+	// XFA is true if the form contains XFA data, which is not
+	// filled by pdftk.
+	XFA bool
+
+	// Pages is the number of pages. It is zero for encrypted files.
+	Pages int
+}
+
+var pdfHeaderRegexp = regexp.MustCompile(`%PDF-(\d\.\d)`)
+
+// Analyze returns information about the PDF file, so invalid files can be
+// rejected before a fill is attempted. An error is returned if the file is
+// damaged and can not be read by pdftk.
+func Analyze(pdfFile io.Reader) (info Info, err error) {
+	return DefaultFiller.Analyze(pdfFile)
+}
+
+// Analyze returns information about the PDF file.
+// See the package-level Analyze.
+func (f *Filler) Analyze(pdfFile io.Reader) (info Info, err error) {
+	data, err := io.ReadAll(pdfFile)
+	if err != nil {
+		return info, fmt.Errorf("failed to read the PDF file: %v", err)
+	}
+
+	// The header must be located within the first 1024 bytes.
+	header := data
+	if len(header) > 1024 {
+		header = header[:1024]
+	}
+	m := pdfHeaderRegexp.FindSubmatch(header)
+	if m == nil {
+		return info, fmt.Errorf("invalid PDF file: missing header")
+	}
+	info.Version = string(m[1])
+
+	if !bytes.Contains(data[len(data)-min(len(data), 1024):], []byte("%%EOF")) {
+		return info, fmt.Errorf("damaged PDF file: missing end-of-file marker")
+	}
+
+	s := newScanner(data)
+	info.Encrypted = s.hasName("Encrypt")
+	info.AcroForm = s.hasName("AcroForm")
+	info.XFA = s.hasName("XFA")
+
+	if info.Encrypted {
+		return info, nil
+	}
+
+	info.Pages, err = f.readPageCount(bytes.NewReader(data))
+	if err != nil {
+		return info, err
+	}
+
+	return info, nil
+}
+
+// readPageCount returns the number of pages of the PDF file.
+func (f *Filler) readPageCount(pdfFile io.Reader) (int, error) {
+	out, err := f.output(PriorityNormal, pdfFile, "-", "dump_data_utf8", "output", "-")
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "NumberOfPages: ")
+		if ok {
+			return strconv.Atoi(value)
+		}
+	}
+
+	return 0, fmt.Errorf("pdftk error: missing page count")
+}
+
+// readInfo returns the document information entries of the PDF file.
+func (f *Filler) readInfo(pdfFile io.Reader) (map[string]string, error) {
+	out, err := f.output(PriorityNormal, pdfFile, "-", "dump_data_utf8", "output", "-")
+	if err != nil {
+		return nil, err
+	}
+
+	info := make(map[string]string)
+	var key string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if k, ok := strings.CutPrefix(line, "InfoKey: "); ok {
+			key = k
+		} else if v, ok := strings.CutPrefix(line, "InfoValue: "); ok && key != "" {
+			info[key] = v
+			key = ""
+		}
+	}
+	return info, scanner.Err()
+}
+
+// updateInfo applies the pdftk info data, like document information
+// entries or bookmarks, to the PDF file.
+func (f *Filler) updateInfo(p Priority, pdfFile []byte, data []byte) ([]byte, error) {
+	tmp, err := os.CreateTemp(f.tempDir, "fillpdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.output(p, bytes.NewReader(pdfFile), "-", "update_info_utf8", tmp.Name(), "output", "-")
+}
+
+// scanner searches the raw PDF data without the stream data and the
+// contents of its compressed object streams for PDF tokens. Other
+// streams, like page contents, are not searched, because their text may
+// contain any name.
+type scanner struct {
+	parts [][]byte
+}
+
+var (
+	streamRegexp = regexp.MustCompile(`stream\r?\n`)
+	objStmRegexp = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+)
+
+// maxInflatedSize limits the total size of the inflated object streams,
+// which protects against decompression bombs in uploaded files.
+const maxInflatedSize = 64 * 1024 * 1024
+
+func newScanner(data []byte) *scanner {
+	var (
+		s         = &scanner{parts: [][]byte{nil}}
+		raw       = make([]byte, 0, len(data))
+		last      int
+		remaining = int64(maxInflatedSize)
+	)
+	for _, loc := range streamRegexp.FindAllIndex(data, -1) {
+		// Skip the endstream keywords.
+		if loc[0] >= 3 && string(data[loc[0]-3:loc[0]]) == "end" {
+			continue
+		}
+
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+
+		// The stream data is not searched.
+		raw = append(raw, data[last:start]...)
+		last = start + end
+
+		// The stream dictionary follows the last object header.
+		dict := data[:loc[0]]
+		if i := bytes.LastIndex(dict, []byte(" obj")); i >= 0 {
+			dict = dict[i:]
+		}
+		if !objStmRegexp.Match(dict) || remaining <= 0 {
+			continue
+		}
+
+		zr, err := zlib.NewReader(bytes.NewReader(data[start : start+end]))
+		if err != nil {
+			continue
+		}
+		content, _ := io.ReadAll(io.LimitReader(zr, remaining))
+		zr.Close()
+		remaining -= int64(len(content))
+		if len(content) > 0 {
+			s.parts = append(s.parts, content)
+		}
+	}
+
+	s.parts[0] = append(raw, data[last:]...)
+	return s
+}
+
+// hasName returns whether the PDF name object occurs in the data.
+func (s *scanner) hasName(name string) bool {
+	return s.count(name) > 0
+}
+
+// count returns the number of occurrences of the PDF name object.
+func (s *scanner) count(name string) (n int) {
+	token := []byte("/" + name)
+	for _, data := range s.parts {
+		for pos := 0; ; {
+			i := bytes.Index(data[pos:], token)
+			if i < 0 {
+				break
+			}
+			pos += i + len(token)
+			if pos == len(data) || isDelimiter(data[pos]) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// isDelimiter returns whether the byte terminates a PDF name.
+func isDelimiter(b byte) bool {
+	switch b {
+	case ' ', '\t', '\r', '\n', '\f', 0,
+		'(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
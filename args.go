@@ -0,0 +1,162 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// pdftkKeywords are the keywords of the operations and options of pdftk.
+var pdftkKeywords = map[string]bool{
+	"input_pw": true, "cat": true, "shuffle": true, "burst": true, "rotate": true,
+	"generate_fdf": true, "fill_form": true, "background": true, "multibackground": true,
+	"stamp": true, "multistamp": true, "dump_data": true, "dump_data_utf8": true,
+	"dump_data_fields": true, "dump_data_fields_utf8": true, "dump_data_annots": true,
+	"update_info": true, "update_info_utf8": true, "attach_files": true, "unpack_files": true,
+	"output": true, "verbose": true, "dont_ask": true, "do_ask": true, "flatten": true,
+	"need_appearances": true, "compress": true, "uncompress": true, "keep_first_id": true,
+	"keep_final_id": true, "drop_xfa": true, "drop_xmp": true, "encrypt_40bit": true,
+	"encrypt_128bit": true, "encrypt_aes128": true, "allow": true, "owner_pw": true,
+	"user_pw": true, "to_page": true, "relative_to": true, "replacement_font": true,
+}
+
+// pdftkOperations are the operations accepted by PdftkArgs.
+var pdftkOperations = []string{
+	"cat", "shuffle", "burst", "rotate", "generate_fdf", "fill_form", "background",
+	"multibackground", "stamp", "multistamp", "dump_data", "dump_data_utf8",
+	"dump_data_fields", "dump_data_fields_utf8", "dump_data_annots", "update_info",
+	"update_info_utf8", "attach_files", "unpack_files",
+}
+
+// safePath returns the file path in a form which pdftk can't mistake for
+// a keyword, an input handle like "A=file.pdf" or a page range.
+// Relative paths are prefixed with "./". The path "-" for stdin and
+// stdout is returned unchanged.
+func safePath(path string) string {
+	if path == "-" || path == "" || filepath.IsAbs(path) ||
+		strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		return path
+	}
+	return "." + string(filepath.Separator) + path
+}
+
+// PdftkArgs builds the arguments of RunPdftk from untrusted file paths,
+// so a file named like a pdftk keyword, like "output" or "burst", is not
+// misinterpreted as operation:
+//
+//	args, err := fillpdf.NewPdftkArgs().
+//		Input(path).
+//		Operation("burst").
+//		Output("page_%02d.pdf").
+//		Build()
+type PdftkArgs struct {
+	inputs    []string
+	operation []string
+	output    []string
+	options   []string
+	err       error
+}
+
+// NewPdftkArgs creates a new argument builder.
+func NewPdftkArgs() *PdftkArgs {
+	return &PdftkArgs{}
+}
+
+// Input adds an input file. Use "-" for stdin.
+func (a *PdftkArgs) Input(path string) *PdftkArgs {
+	if path == "" {
+		a.setErr(fmt.Errorf("empty input file path"))
+	}
+	a.inputs = append(a.inputs, safePath(path))
+	return a
+}
+
+// Operation sets the operation, like "cat" or "stamp", and its
+// arguments. Arguments which are file paths must be added with
+// OperationFile.
+func (a *PdftkArgs) Operation(name string, args ...string) *PdftkArgs {
+	valid := false
+	for _, op := range pdftkOperations {
+		if name == op {
+			valid = true
+		}
+	}
+	if !valid {
+		a.setErr(fmt.Errorf("invalid pdftk operation: '%s'", name))
+	} else if a.operation != nil {
+		a.setErr(fmt.Errorf("pdftk operation is already set"))
+	}
+	a.operation = append([]string{name}, args...)
+	return a
+}
+
+// OperationFile adds a file path argument to the operation, like the
+// stamp PDF file of "stamp".
+func (a *PdftkArgs) OperationFile(path string) *PdftkArgs {
+	if a.operation == nil {
+		a.setErr(fmt.Errorf("pdftk operation is not set"))
+	} else if path == "" {
+		a.setErr(fmt.Errorf("empty operation file path"))
+	}
+	a.operation = append(a.operation, safePath(path))
+	return a
+}
+
+// Output sets the output file. Use "-" for stdout.
+func (a *PdftkArgs) Output(path string) *PdftkArgs {
+	if path == "" {
+		a.setErr(fmt.Errorf("empty output file path"))
+	}
+	a.output = []string{"output", safePath(path)}
+	return a
+}
+
+// Options adds output options, like "flatten" or "user_pw", "secret".
+// The first option must be a pdftk keyword.
+func (a *PdftkArgs) Options(options ...string) *PdftkArgs {
+	if len(options) > 0 && !pdftkKeywords[options[0]] {
+		a.setErr(fmt.Errorf("invalid pdftk option: '%s'", options[0]))
+	}
+	a.options = append(a.options, options...)
+	return a
+}
+
+// Build returns the arguments or the first error of the builder.
+func (a *PdftkArgs) Build() ([]string, error) {
+	if a.err != nil {
+		return nil, a.err
+	} else if len(a.inputs) == 0 {
+		return nil, fmt.Errorf("missing pdftk input file")
+	} else if a.output == nil {
+		return nil, fmt.Errorf("missing pdftk output file")
+	}
+
+	args := append([]string(nil), a.inputs...)
+	args = append(args, a.operation...)
+	args = append(args, a.output...)
+	return append(args, a.options...), nil
+}
+
+func (a *PdftkArgs) setErr(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Part is a template of an assembled document.
+type Part struct {
+	Template *Template
+
+	// Title names the part in the bookmarks and the table of contents.
+	Title string
+
+	// Overrides take precedence over the values of the assembly form
+	// for this part only.
+	Overrides Form
+}
+
+// Assembly describes a document assembled from several filled templates,
+// like a cover letter, an application and disclosures.
+type Assembly struct {
+	// Parts are filled and concatenated in this order.
+	Parts []Part
+
+	// Options are used to fill every part. Flatten the parts to keep
+	// the values of fields with the same name in different parts apart.
+	Options Options
+
+	// Bookmarks adds a bookmark to the first page of every part with a title.
+	Bookmarks bool
+
+	// TOC prepends a table of contents page listing the parts with a title.
+	TOC bool
+
+	// TOCTitle is the title of the table of contents. Defaults to "Contents".
+	TOCTitle string
+}
+
+// Assemble fills every part of the assembly with the form and
+// concatenates the filled PDF files to a single PDF file.
+// Fields with the same name in several parts, like "ApplicantName",
+// are filled with the same form value, unless a part overrides it.
+func Assemble(form Form, a Assembly) (io.Reader, error) {
+	return DefaultFiller.Assemble(form, a)
+}
+
+// Assemble fills every part of the assembly with the form and
+// concatenates the filled PDF files. See the package-level Assemble.
+// The parts are filled by f, not by the fillers of their templates.
+func (f *Filler) Assemble(form Form, a Assembly) (io.Reader, error) {
+	if len(a.Parts) == 0 {
+		return nil, fmt.Errorf("assembly has no parts")
+	}
+
+	prio := a.Options.Priority
+	parts := make([][]byte, len(a.Parts))
+	for i, p := range a.Parts {
+		partForm := form
+		if len(p.Overrides) > 0 {
+			partForm = form.Merge(p.Overrides)
+		}
+
+		r, err := p.Template.fillWith(f, partForm, a.Options)
+		if err == nil {
+			parts[i], err = io.ReadAll(r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("part %d: %v", i, err)
+		}
+	}
+
+	if !a.Bookmarks && !a.TOC {
+		return f.mergeResults(prio, readers(parts))
+	}
+
+	// The first page of every part.
+	starts := make([]int, len(parts))
+	page := 1
+	for i, part := range parts {
+		starts[i] = page
+		n, err := f.readPageCount(bytes.NewReader(part))
+		if err != nil {
+			return nil, fmt.Errorf("part %d: %v", i, err)
+		}
+		page += n
+	}
+
+	if a.TOC {
+		toc := a.tocSection(starts, 0)
+		offset := len(layoutTextPages([]Section{toc}))
+		// The page numbers may change the wrapping of the titles.
+		toc = a.tocSection(starts, offset)
+		if n := len(layoutTextPages([]Section{toc})); n != offset {
+			offset = n
+			toc = a.tocSection(starts, offset)
+		}
+
+		for i := range starts {
+			starts[i] += offset
+		}
+		parts = append([][]byte{renderTextPDF([]Section{toc})}, parts...)
+	}
+
+	merged, err := f.mergeResults(prio, readers(parts))
+	if err != nil || !a.Bookmarks {
+		return merged, err
+	}
+
+	out, err := io.ReadAll(merged)
+	if err != nil {
+		return nil, err
+	}
+	out, err = f.addBookmarks(prio, out, a.Parts, starts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add the bookmarks: %v", err)
+	}
+	return bytes.NewReader(out), nil
+}
+
+// tocSection returns the table of contents of the parts starting at the
+// pages, which are shifted by the offset.
+func (a Assembly) tocSection(starts []int, offset int) Section {
+	toc := Section{Title: a.TOCTitle}
+	if toc.Title == "" {
+		toc.Title = "Contents"
+	}
+	for i, p := range a.Parts {
+		if p.Title != "" {
+			toc.Lines = append(toc.Lines, fmt.Sprintf("%s - page %d", p.Title, starts[i]+offset))
+		}
+	}
+	return toc
+}
+
+// addBookmarks adds a bookmark for every part with a title to the PDF file.
+func (f *Filler) addBookmarks(p Priority, pdfFile []byte, parts []Part, starts []int) ([]byte, error) {
+	var info bytes.Buffer
+	for i, part := range parts {
+		if part.Title == "" {
+			continue
+		}
+		title := strings.Join(strings.Fields(part.Title), " ")
+		fmt.Fprintf(&info, "BookmarkBegin\nBookmarkTitle: %s\nBookmarkLevel: 1\nBookmarkPageNumber: %d\n", title, starts[i])
+	}
+
+	return f.updateInfo(p, pdfFile, info.Bytes())
+}
+
+// readers returns readers of the data.
+func readers(data [][]byte) []io.Reader {
+	r := make([]io.Reader, len(data))
+	for i, d := range data {
+		r[i] = bytes.NewReader(d)
+	}
+	return r
+}
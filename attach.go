@@ -0,0 +1,70 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attachment is a file embedded into a PDF file.
+type Attachment struct {
+	// Name is the file name of the attachment. Only its base name is used,
+	// which must be unique among the attachments of a fill.
+	Name string
+	Data []byte
+}
+
+// attachFiles embeds the attachments into the PDF file.
+func (f *Filler) attachFiles(p Priority, pdfFile []byte, attachments []Attachment) ([]byte, error) {
+	// pdftk reads the attachments from disk and uses the file names.
+	dir, err := os.MkdirTemp(f.tempDir, "fillpdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"-", "attach_files"}
+	names := make(map[string]bool, len(attachments))
+	for _, a := range attachments {
+		name := filepath.Base(a.Name)
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			return nil, fmt.Errorf("invalid attachment name: '%s'", a.Name)
+		}
+
+		// Case-insensitive file systems would overwrite the files, too.
+		if names[strings.ToLower(name)] {
+			return nil, fmt.Errorf("duplicate attachment name: '%s'", name)
+		}
+		names[strings.ToLower(name)] = true
+
+		path := filepath.Join(dir, name)
+		err = os.WriteFile(path, a.Data, 0600)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, path)
+	}
+	args = append(args, "output", "-")
+
+	return f.output(p, bytes.NewReader(pdfFile), args...)
+}
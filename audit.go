@@ -0,0 +1,84 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// FillEvent describes a finished fill for audit logs.
+// It never contains the form values.
+type FillEvent struct {
+	// Template is the path of the form PDF file.
+	// It is empty for PDF files read from readers.
+	Template string
+
+	// RequestID is the caller supplied request ID of the options.
+	RequestID string
+
+	// Fields are the sorted names of the filled fields.
+	Fields []string
+
+	// Hashes contains the hex encoded HMAC-SHA256 of the form values with
+	// the AuditKey of the Filler by field name if enabled by the
+	// HashAuditValues option. Values like dates of birth are easily
+	// guessed, therefore plain hashes would not hide them.
+	Hashes map[string]string
+
+	Duration time.Duration
+
+	// Err is nil if the fill succeeded.
+	Err error
+}
+
+// emitFillEvent passes the fill event to the OnFill callback of the options.
+// The values are hashed with the key.
+func emitFillEvent(opts Options, key []byte, template string, form Form, start time.Time, err error) {
+	if opts.OnFill == nil {
+		return
+	}
+
+	e := FillEvent{
+		Template:  template,
+		RequestID: opts.RequestID,
+		Fields:    make([]string, 0, len(form)),
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+
+	for name := range form {
+		e.Fields = append(e.Fields, name)
+	}
+	sort.Strings(e.Fields)
+
+	if opts.HashAuditValues && len(key) > 0 {
+		e.Hashes = make(map[string]string, len(form))
+		for name, v := range form {
+			h := hmac.New(sha256.New, key)
+			h.Write([]byte(formatValue(v)))
+			e.Hashes[name] = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+
+	opts.OnFill(e)
+}
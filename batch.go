@@ -0,0 +1,574 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// BatchOptions are the options of FillBatchWithOptions.
+type BatchOptions struct {
+	Options
+
+	// OnProgress is called after every processed record.
+	// Calls are not concurrent.
+	OnProgress func(BatchProgress)
+
+	// FailFast stops the batch at the first failed record
+	// and returns its error.
+	FailFast bool
+
+	// Merge concatenates the successfully filled PDF files to a single
+	// PDF file. Flatten the records to keep their field values apart.
+	// The records are still filled by one pdftk process each.
+	Merge bool
+
+	// Checkpoint persists the filled PDF files of the processed records,
+	// so an interrupted batch resumes without filling them again.
+	Checkpoint Checkpoint
+
+	// RecordID returns the checkpoint ID of the record.
+	// Defaults to the index of the record.
+	RecordID func(index int, form Form) string
+
+	// Password returns the user password of the record, like an employee
+	// ID combined with the date of birth, which overrides the UserPassword
+	// option. Encrypted records can't be merged.
+	Password func(index int, form Form) string
+
+	// Name is a text/template rendered with the form values of every
+	// record to the file name of its filled PDF file, like
+	// "{{.EmployeeID}}_{{.Month}}.pdf". Duplicate names, compared
+	// case-insensitively, fail the batch before any record is filled.
+	Name string
+
+	// Deliver is called with every successfully filled record, like
+	// WebhookDelivery or SMTPDelivery. Calls are concurrent.
+	// Records failing to be delivered are failed records of the batch.
+	// Records are saved to the checkpoint after they are delivered, so
+	// failed deliveries are retried by the next run and records loaded
+	// from the checkpoint are not delivered again.
+	Deliver func(Record) error
+
+	// Zip streams the filled records as ZIP archive to the writer, named
+	// by the Name option or "<index>.pdf". The entries are written in the
+	// order the records finish. The Results of the batch are nil, so the
+	// records are not kept in memory. The writer is not closed.
+	Zip io.Writer
+}
+
+// Checkpoint persists the progress of a batch fill.
+// Implementations must be safe for concurrent use.
+type Checkpoint interface {
+	// Load returns the filled PDF file of a processed record.
+	Load(id string) (data []byte, ok bool, err error)
+
+	// Save persists the filled PDF file of a processed record.
+	Save(id string, data []byte) error
+}
+
+// BatchResult is the result of a batch fill.
+type BatchResult struct {
+	// Results are the filled PDF files in the order of the forms.
+	// They are nil for failed and skipped records.
+	Results []io.Reader
+
+	// Failures are the errors of the failed records.
+	Failures BatchError
+
+	// Merged is the merged PDF file of the successful records,
+	// if the Merge option is set.
+	Merged io.Reader
+
+	// Names are the file names of the records in the order of the forms,
+	// if the Name option is set.
+	Names []string
+}
+
+// WriteDir writes the filled PDF files of the successful records to the
+// directory named by the Names of the result.
+// The results are replaced by readers of their data.
+func (r *BatchResult) WriteDir(dir string) error {
+	if len(r.Names) != len(r.Results) {
+		return fmt.Errorf("the batch has no file names")
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for i, result := range r.Results {
+		if result == nil {
+			continue
+		}
+
+		data, err := io.ReadAll(result)
+		if err != nil {
+			return err
+		}
+		r.Results[i] = bytes.NewReader(data)
+
+		err = os.WriteFile(filepath.Join(dir, r.Names[i]), data, 0600)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchProgress is the progress of a batch fill.
+type BatchProgress struct {
+	Total  int
+	Done   int
+	Failed int
+
+	// Resumed is the number of records loaded from the checkpoint.
+	Resumed int
+
+	// ETA is the estimated remaining duration.
+	ETA time.Duration
+}
+
+// RecordError is the error of a single record of a batch fill.
+type RecordError struct {
+	Index int
+	Err   error
+}
+
+func (e RecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the record.
+func (e RecordError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError contains the errors of the failed records of a batch fill
+// ordered by the record index.
+type BatchError []RecordError
+
+func (e BatchError) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%d records failed, first %v", len(e), e[0])
+}
+
+// FillBatch fills the PDF form once for every specified form and returns
+// the filled PDF files in the same order.
+// pdftk fills only a single record per invocation, so every record still
+// starts its own pdftk process and the process startup is not amortized.
+// Only the form file is resolved and its fields are read once, and the
+// fills are spread over one process per CPU.
+func FillBatch(forms []Form, formPDFFile string) (results []io.Reader, err error) {
+	return DefaultFiller.FillBatch(forms, formPDFFile)
+}
+
+// FillBatchWithOptions fills the PDF form once for every specified form
+// with the options. See the Filler method.
+func FillBatchWithOptions(forms []Form, formPDFFile string, opts BatchOptions) (*BatchResult, error) {
+	return DefaultFiller.FillBatchWithOptions(forms, formPDFFile, opts)
+}
+
+// FillBatch fills the PDF form once for every specified form with the
+// default options of the filler. See the package-level FillBatch.
+func (f *Filler) FillBatch(forms []Form, formPDFFile string) (results []io.Reader, err error) {
+	r, err := f.FillBatchWithOptions(forms, formPDFFile, BatchOptions{
+		Options:  f.opts,
+		FailFast: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Results, nil
+}
+
+// FillBatchWithOptions fills the PDF form once for every specified form
+// with the options. Failed records don't stop the batch, unless the
+// FailFast option is set, and are reported as failures of the result.
+// Like FillBatch, it runs at least one pdftk process per record.
+// The Merge option runs one more process to concatenate the records.
+func (f *Filler) FillBatchWithOptions(forms []Form, formPDFFile string, opts BatchOptions) (*BatchResult, error) {
+	if opts.Merge && (opts.Password != nil || opts.UserPassword != "") {
+		return nil, fmt.Errorf("encrypted records can't be merged")
+	}
+
+	if opts.Merge && opts.Zip != nil {
+		return nil, fmt.Errorf("zipped records can't be merged")
+	}
+
+	var names []string
+	if opts.Name != "" {
+		var err error
+		names, err = recordNames(forms, opts.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	formPDFFile, err := resolveFormFile(formPDFFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		errs     BatchError
+		failed   bool
+		progress = BatchProgress{Total: len(forms)}
+		start    = time.Now()
+		sem      = make(chan struct{}, runtime.NumCPU())
+	)
+
+	// The fields are read only once for all records.
+	var (
+		fieldsOnce sync.Once
+		fields     []Field
+		fieldsErr  error
+	)
+	loadFields := func() ([]Field, error) {
+		fieldsOnce.Do(func() {
+			fields, fieldsErr = f.readFields(formPDFFile)
+		})
+		return fields, fieldsErr
+	}
+
+	results := make([]io.Reader, len(forms))
+
+	var (
+		zw     *zip.Writer
+		zipErr error
+	)
+	if opts.Zip != nil {
+		zw = zip.NewWriter(opts.Zip)
+	}
+
+	for i, form := range forms {
+		sem <- struct{}{}
+
+		mutex.Lock()
+		stop := failed && opts.FailFast
+		mutex.Unlock()
+		if stop {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+
+		go func(i int, form Form) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			r, resumed, err := f.fillRecord(i, form, recordName(i, names), formPDFFile, opts, loadFields)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err == nil && zw != nil {
+				// A failed write breaks the archive.
+				if zipErr == nil {
+					zipErr = writeZipEntry(zw, recordName(i, names), r)
+				}
+				err = zipErr
+				r = nil
+			}
+
+			progress.Done++
+			if resumed {
+				progress.Resumed++
+			}
+			if err != nil {
+				progress.Failed++
+				failed = true
+				errs = append(errs, RecordError{Index: i, Err: err})
+			} else {
+				results[i] = r
+			}
+
+			if opts.OnProgress != nil {
+				// Resumed records take no time.
+				if filled := progress.Done - progress.Resumed; filled > 0 {
+					progress.ETA = time.Since(start) / time.Duration(filled) * time.Duration(progress.Total-progress.Done)
+				}
+				opts.OnProgress(progress)
+			}
+		}(i, form)
+	}
+
+	wg.Wait()
+
+	if zw != nil {
+		if zipErr == nil {
+			zipErr = zw.Close()
+		}
+		if zipErr != nil {
+			return nil, fmt.Errorf("failed to write the ZIP archive: %v", zipErr)
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Index < errs[j].Index
+	})
+	if len(errs) > 0 && opts.FailFast {
+		return nil, errs[0]
+	}
+
+	r := &BatchResult{
+		Results:  results,
+		Failures: errs,
+		Names:    names,
+	}
+
+	if opts.Merge {
+		r.Merged, err = f.mergeResults(opts.Priority, results)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge the records: %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+// fillRecord fills and delivers a single record of a batch. Records
+// processed by an earlier run are loaded from the checkpoint instead.
+func (f *Filler) fillRecord(i int, form Form, name, formPDFFile string, opts BatchOptions, fields fieldsLoader) (result io.Reader, resumed bool, err error) {
+	if opts.Password != nil {
+		opts.UserPassword = opts.Password(i, form)
+	}
+
+	if opts.Checkpoint == nil {
+		result, err = f.fillFile(form, formPDFFile, opts.Options, fields)
+		if err == nil && opts.Deliver != nil {
+			result, err = deliverRecord(opts.Deliver, Record{Index: i, Form: form, Name: name, PDF: result})
+		}
+		return result, false, err
+	}
+
+	id := strconv.Itoa(i)
+	if opts.RecordID != nil {
+		id = opts.RecordID(i, form)
+	}
+
+	data, ok, err := opts.Checkpoint.Load(id)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load the checkpoint: %v", err)
+	} else if ok {
+		return bytes.NewReader(data), true, nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = f.fillFileTo(buf, form, formPDFFile, opts.Options, fields)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The record is checkpointed after the delivery, so failed
+	// deliveries are retried.
+	if opts.Deliver != nil {
+		_, err = deliverRecord(opts.Deliver, Record{Index: i, Form: form, Name: name, PDF: bytes.NewReader(buf.Bytes())})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	err = opts.Checkpoint.Save(id, buf.Bytes())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save the checkpoint: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes()), false, nil
+}
+
+// recordName returns the file name of the record.
+func recordName(i int, names []string) string {
+	if names == nil {
+		return strconv.Itoa(i) + ".pdf"
+	}
+	return names[i]
+}
+
+// writeZipEntry writes the filled PDF file as entry of the ZIP archive.
+func writeZipEntry(zw *zip.Writer, name string, r io.Reader) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// deliverRecord passes the record to the delivery hook and returns
+// a new reader of its filled PDF file.
+func deliverRecord(deliver func(Record) error, r Record) (io.Reader, error) {
+	data, err := io.ReadAll(r.PDF)
+	if err != nil {
+		return nil, err
+	}
+
+	r.PDF = bytes.NewReader(data)
+	err = deliver(r)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// recordNames renders the file names of the records with the name template.
+// Names which are equal ignoring case fail.
+func recordNames(forms []Form, name string) ([]string, error) {
+	t, err := template.New("name").Option("missingkey=error").Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name template: %v", err)
+	}
+
+	names := make([]string, len(forms))
+	indexes := make(map[string]int, len(forms))
+	for i, form := range forms {
+		var b strings.Builder
+		err = t.Execute(&b, form)
+		if err != nil {
+			return nil, RecordError{Index: i, Err: fmt.Errorf("failed to render the name: %v", err)}
+		}
+
+		n := b.String()
+		if n == "" || !filepath.IsLocal(n) || strings.ContainsAny(n, `/\`) {
+			return nil, RecordError{Index: i, Err: fmt.Errorf("invalid file name: '%s'", n)}
+		}
+
+		// Names differing in case collide on case-insensitive file systems.
+		key := strings.ToLower(n)
+		if j, ok := indexes[key]; ok {
+			return nil, RecordError{Index: i, Err: fmt.Errorf("file name '%s' is used by record %d", n, j)}
+		}
+		indexes[key] = i
+		names[i] = n
+	}
+	return names, nil
+}
+
+// DirCheckpoint is a Checkpoint storing the filled PDF file of every
+// processed record as file named by the record ID in a directory.
+type DirCheckpoint string
+
+// Load reads the file of the record.
+func (d DirCheckpoint) Load(id string) ([]byte, bool, error) {
+	path, err := d.path(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Save writes the file of the record atomically, so interrupted
+// writes are not mistaken for processed records.
+func (d DirCheckpoint) Save(id string, data []byte) error {
+	path, err := d.path(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(string(d), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// path returns the file path of the record.
+func (d DirCheckpoint) path(id string) (string, error) {
+	if id == "" || !filepath.IsLocal(id) || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid record ID: '%s'", id)
+	}
+	return filepath.Join(string(d), id+".pdf"), nil
+}
+
+// mergeResults concatenates the non-nil filled PDF files.
+// The results are replaced by readers of their data.
+func (f *Filler) mergeResults(p Priority, results []io.Reader) (io.Reader, error) {
+	// pdftk reads the files to concatenate from disk.
+	dir, err := os.MkdirTemp(f.tempDir, "fillpdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var args []string
+	for i, r := range results {
+		if r == nil {
+			continue
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = bytes.NewReader(data)
+
+		path := filepath.Join(dir, strconv.Itoa(i)+".pdf")
+		err = os.WriteFile(path, data, 0600)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, path)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no records were filled")
+	}
+	args = append(args, "cat", "output", "-")
+
+	out, err := f.output(p, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
@@ -0,0 +1,207 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchmarkForm returns a form with n text, number and checkbox values.
+func benchmarkForm(n int) Form {
+	form := make(Form, n)
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			form[fmt.Sprintf("text_%d", i)] = fmt.Sprintf("Value (%d) of the field", i)
+		case 1:
+			form[fmt.Sprintf("number_%d", i)] = float64(i) * 1.5
+		default:
+			form[fmt.Sprintf("check_%d", i)] = i%2 == 0
+		}
+	}
+	return form
+}
+
+// benchmarkFields returns the fields of the benchmark form.
+func benchmarkFields(form Form) fieldsLoader {
+	fields := make([]Field, 0, len(form))
+	for _, k := range form.Keys() {
+		fields = append(fields, Field{Name: k, Type: FieldTypeText, MaxLength: 64})
+	}
+	return func() ([]Field, error) {
+		return fields, nil
+	}
+}
+
+func BenchmarkPrepareForm(b *testing.B) {
+	for _, n := range []int{10, 1000} {
+		form := benchmarkForm(n)
+		fields := benchmarkFields(form)
+
+		b.Run(fmt.Sprintf("fields=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _, err := prepareForm(form, Options{}, fields)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("fields=%d/validate", n), func(b *testing.B) {
+			b.ReportAllocs()
+			opts := Options{Validate: true, SpillLongValues: true}
+			for i := 0; i < b.N; i++ {
+				_, _, err := prepareForm(form, opts, fields)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkFiller returns a filler running pdftk.
+// The benchmark is skipped if pdftk is not installed.
+func benchmarkFiller(b *testing.B) *Filler {
+	if _, err := exec.LookPath("pdftk"); err != nil {
+		b.Skip("pdftk is not installed")
+	}
+	return NewFiller(FillerConfig{})
+}
+
+func BenchmarkFill(b *testing.B) {
+	f := benchmarkFiller(b)
+	form := Form{"field_1": "Hello", "field_2": "World"}
+
+	for _, fast := range []bool{false, true} {
+		b.Run(fmt.Sprintf("fast=%v", fast), func(b *testing.B) {
+			opts := Options{Fast: fast}
+			for i := 0; i < b.N; i++ {
+				err := f.FillTo(io.Discard, form, "sample/form.pdf", opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFillBatch(b *testing.B) {
+	f := benchmarkFiller(b)
+	forms := make([]Form, 10)
+	for i := range forms {
+		forms[i] = Form{"field_1": fmt.Sprintf("Record %d", i)}
+	}
+
+	for i := 0; i < b.N; i++ {
+		_, err := f.FillBatch(forms, "sample/form.pdf")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkTemplate writes a form PDF file with n text fields named
+// "text_<i>" to a temporary directory and returns its path and a form
+// filling all fields.
+func benchmarkTemplate(b *testing.B, n int) (string, Form) {
+	var (
+		form    = make(Form, n)
+		fields  = make([]string, n)
+		objects = make([]string, 4, 4+n)
+	)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("text_%d", i)
+		form[name] = fmt.Sprintf("Value (%d) of the field", i)
+		fields[i] = fmt.Sprintf("%d 0 R", 5+i)
+		objects = append(objects, fmt.Sprintf("<< /Type /Annot /Subtype /Widget /FT /Tx /T (%s) "+
+			"/Rect [%d %d %d %d] /P 4 0 R /DA (/Helv 8 Tf 0 g) >>",
+			name, 20+i%4*140, 20+i/4%80*10, 150+i%4*140, 29+i/4%80*10))
+	}
+	refs := strings.Join(fields, " ")
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [" + refs + "] " +
+		"/DA (/Helv 8 Tf 0 g) /DR << /Font << /Helv 3 0 R >> >> >> >>"
+	objects[1] = "<< /Type /Pages /Kids [4 0 R] /Count 1 >>"
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+	objects[3] = "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 842] /Annots [" + refs + "] >>"
+
+	path := filepath.Join(b.TempDir(), "form.pdf")
+	err := os.WriteFile(path, writePDFObjects(objects), 0o600)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return path, form
+}
+
+func BenchmarkFillLarge(b *testing.B) {
+	f := benchmarkFiller(b)
+
+	for _, n := range []int{100, 1000} {
+		path, form := benchmarkTemplate(b, n)
+
+		for _, fast := range []bool{false, true} {
+			b.Run(fmt.Sprintf("fields=%d/fast=%v", n, fast), func(b *testing.B) {
+				opts := Options{Fast: fast}
+				for i := 0; i < b.N; i++ {
+					err := f.FillTo(io.Discard, form, path, opts)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkFillCommand fills through a command set with SetCommand.
+// It runs pdftk through env by default, which measures the overhead of
+// the command prefix. Set FILLPDF_BENCH_COMMAND to measure a helper
+// process, like "ng com.gitlab.pdftk_java.pdftk" for a nailgun server.
+func BenchmarkFillCommand(b *testing.B) {
+	command := strings.Fields(os.Getenv("FILLPDF_BENCH_COMMAND"))
+	if len(command) == 0 {
+		command = []string{"env", "pdftk"}
+		benchmarkFiller(b)
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		b.Skipf("%s is not installed", command[0])
+	}
+
+	f := NewFiller(FillerConfig{})
+	f.SetCommand(command[0], command[1:]...)
+	form := Form{"field_1": "Hello", "field_2": "World"}
+
+	for _, fast := range []bool{false, true} {
+		b.Run(fmt.Sprintf("fast=%v", fast), func(b *testing.B) {
+			opts := Options{Fast: fast}
+			for i := 0; i < b.N; i++ {
+				err := f.FillTo(io.Discard, form, "sample/form.pdf", opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Cache stores filled PDF files by a key derived from the template,
+// the form values and the options. Implementations must be safe for
+// concurrent use. Backends like Redis are plugged in by implementing
+// this interface.
+type Cache interface {
+	Get(key string) (data []byte, ok bool)
+	Set(key string, data []byte)
+}
+
+// LRUCache is an in-memory Cache evicting the least recently used entries.
+type LRUCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type lruEntry struct {
+	key  string
+	data []byte
+}
+
+// NewLRUCache creates a new in-memory cache holding at most maxEntries entries.
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached data of the key.
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*lruEntry).data, true
+}
+
+// Set caches the data with the key.
+func (c *LRUCache) Set(key string, data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*lruEntry).data = data
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, data: data})
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *LRUCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.ll.Len()
+}
+
+// cachedFill writes the cached filled PDF file to w or calls fill and
+// caches its output.
+func cachedFill(w io.Writer, c Cache, key string, fill func(w io.Writer) error) error {
+	if data, ok := c.Get(key); ok {
+		_, err := w.Write(data)
+		return err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := fill(io.MultiWriter(w, buf))
+	if err != nil {
+		return err
+	}
+
+	c.Set(key, buf.Bytes())
+	return nil
+}
+
+// cacheKey returns the cache key of a fill and whether the fill is reproducible.
+// The template ID identifies the form PDF file. The form and options must
+// be prepared, so the key covers the FDF data which is filled, including
+// the results of encoders, converters, expressions and value policies.
+func cacheKey(templateID string, form Form, opts Options) (string, bool) {
+	// Provenance records, masks, stamps and encryption nonces differ per fill.
+	if opts.Provenance != nil || len(opts.Masks) > 0 || opts.Stamp != nil || opts.UserPassword != "" {
+		return "", false
+	}
+
+	fdfFile := createFdfFile(form, opts)
+	defer releaseFdfFile(fdfFile)
+
+	var id string
+	if opts.EmbedDocumentID {
+		id = opts.DocumentID
+	}
+
+	h := sha256.New()
+	err := json.NewEncoder(h).Encode(struct {
+		Template    string
+		OutputArgs  []string
+		Appendix    []Section
+		Attachments []Attachment
+		Viewer      *ViewerPreferences
+		InitialView *InitialView
+		Lang        string
+		DocumentID  string
+	}{
+		templateID, opts.outputArgs(), opts.Appendix, opts.Attachments,
+		opts.ViewerPreferences, opts.InitialView, opts.Lang, id,
+	})
+	if err != nil {
+		return "", false
+	}
+	h.Write(*fdfFile)
+
+	// The key of the content hash is not stored, only its hash.
+	if opts.EmbedDocumentID && opts.ContentKey != nil {
+		sum := sha256.Sum256(opts.ContentKey)
+		h.Write(sum[:])
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// fileCacheID identifies a template file by its path, size and modification time.
+func fileCacheID(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// dataCacheID identifies template data by its SHA-256 hash.
+func dataCacheID(r io.Reader) (string, error) {
+	h := sha256.New()
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,381 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	startxrefRegexp = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	rootRegexp      = regexp.MustCompile(`/Root\s+(\d+)\s+(\d+)\s+R`)
+	sizeRegexp      = regexp.MustCompile(`/Size\s+(\d+)`)
+	infoRegexp      = regexp.MustCompile(`/Info\s+\d+\s+\d+\s+R`)
+	idRegexp        = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+	refRegexp       = regexp.MustCompile(`^\d+\s+\d+\s+R`)
+	kidsRegexp      = regexp.MustCompile(`\d+\s+\d+\s+R`)
+)
+
+// catalogEntriesFunc returns the entries to set in the document catalog.
+type catalogEntriesFunc func(pdfFile []byte, catalog [][2]string) ([][2]string, error)
+
+// updateCatalog sets the entries of the document catalog of the PDF file
+// with an incremental update, so the original data stays untouched.
+// The values must be PDF objects, like "/UseOutlines".
+// Existing dictionary values are merged with dictionary entries, other
+// existing values are replaced.
+// PDF files with cross-reference streams, which pdftk does not write,
+// are not supported.
+func updateCatalog(pdfFile []byte, entries catalogEntriesFunc) ([]byte, error) {
+	m := startxrefRegexp.FindSubmatch(pdfFile)
+	if m == nil {
+		return nil, fmt.Errorf("invalid PDF file: missing startxref")
+	}
+	prev := string(m[1])
+
+	i := bytes.LastIndex(pdfFile, []byte("trailer"))
+	if i < 0 {
+		return nil, fmt.Errorf("unsupported PDF file: cross-reference streams")
+	}
+	trailer := pdfFile[i:]
+
+	m = rootRegexp.FindSubmatch(trailer)
+	if m == nil {
+		return nil, fmt.Errorf("invalid PDF file: missing catalog")
+	}
+	num, gen := string(m[1]), string(m[2])
+	genNum, _ := strconv.Atoi(gen)
+
+	m = sizeRegexp.FindSubmatch(trailer)
+	if m == nil {
+		return nil, fmt.Errorf("invalid PDF file: missing trailer size")
+	}
+	size := string(m[1])
+
+	catalog, err := findObject(pdfFile, num, gen)
+	if err != nil {
+		return nil, err
+	}
+
+	dict, err := parsePDFDict(catalog)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PDF catalog: %v", err)
+	}
+	add, err := entries(pdfFile, dict)
+	if err != nil {
+		return nil, err
+	} else if len(add) == 0 {
+		return pdfFile, nil
+	}
+	dict, err = mergePDFDict(dict, add)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PDF catalog: %v", err)
+	}
+
+	out := make([]byte, 0, len(pdfFile)+len(catalog)+256)
+	out = append(out, pdfFile...)
+	if !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+
+	offset := len(out)
+	out = fmt.Appendf(out, "%s %s obj\n%s\nendobj\n", num, gen, formatPDFDict(dict))
+
+	xref := len(out)
+	out = fmt.Appendf(out, "xref\n%s 1\n%010d %05d n \n", num, offset, genNum)
+	out = fmt.Appendf(out, "trailer\n<< /Size %s /Root %s %s R /Prev %s", size, num, gen, prev)
+	if info := infoRegexp.Find(trailer); info != nil {
+		out = append(out, ' ')
+		out = append(out, info...)
+	}
+	if id := idRegexp.Find(trailer); id != nil {
+		out = append(out, ' ')
+		out = append(out, id...)
+	}
+	out = fmt.Appendf(out, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+
+	return out, nil
+}
+
+// findObject returns the dictionary of the last definition of the object.
+func findObject(pdfFile []byte, num, gen string) (string, error) {
+	re := regexp.MustCompile(`(?:^|[^0-9])` + num + `\s+` + gen + `\s+obj\b`)
+	locs := re.FindAllIndex(pdfFile, -1)
+	if len(locs) == 0 {
+		return "", fmt.Errorf("invalid PDF file: missing object %s %s", num, gen)
+	}
+
+	start := locs[len(locs)-1][1]
+	end := bytes.Index(pdfFile[start:], []byte("endobj"))
+	if end < 0 {
+		return "", fmt.Errorf("invalid PDF file: unterminated object %s %s", num, gen)
+	}
+	return strings.TrimSpace(string(pdfFile[start : start+end])), nil
+}
+
+// findPage returns the indirect reference of the page with the
+// one-based number by walking the page tree of the catalog.
+func findPage(pdfFile []byte, catalog [][2]string, n int) (string, error) {
+	node := pdfDictValue(catalog, "/Pages")
+	for depth := 0; depth < 32; depth++ {
+		m := refRegexp.FindString(node)
+		if m == "" {
+			return "", fmt.Errorf("invalid PDF file: invalid page tree")
+		}
+		fields := strings.Fields(m)
+
+		obj, err := findObject(pdfFile, fields[0], fields[1])
+		if err != nil {
+			return "", err
+		}
+		dict, err := parsePDFDict(obj)
+		if err != nil {
+			return "", fmt.Errorf("invalid PDF file: invalid page tree: %v", err)
+		}
+
+		if pdfDictValue(dict, "/Type") == "/Page" {
+			if n == 1 {
+				return m, nil
+			}
+			return "", fmt.Errorf("page does not exist")
+		}
+
+		// Descend into the kid containing the page.
+		kids := kidsRegexp.FindAllString(pdfDictValue(dict, "/Kids"), -1)
+		node = ""
+		for _, kid := range kids {
+			count, err := pageCount(pdfFile, kid)
+			if err != nil {
+				return "", err
+			}
+			if n <= count {
+				node = kid
+				break
+			}
+			n -= count
+		}
+		if node == "" {
+			return "", fmt.Errorf("page does not exist")
+		}
+	}
+	return "", fmt.Errorf("invalid PDF file: page tree too deep")
+}
+
+// pageCount returns the number of pages of the page tree node.
+func pageCount(pdfFile []byte, ref string) (int, error) {
+	fields := strings.Fields(ref)
+	obj, err := findObject(pdfFile, fields[0], fields[1])
+	if err != nil {
+		return 0, err
+	}
+	dict, err := parsePDFDict(obj)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PDF file: invalid page tree: %v", err)
+	}
+
+	if pdfDictValue(dict, "/Type") == "/Page" {
+		return 1, nil
+	}
+	return strconv.Atoi(pdfDictValue(dict, "/Count"))
+}
+
+// pdfDictValue returns the source of the value of the key.
+func pdfDictValue(dict [][2]string, key string) string {
+	for _, d := range dict {
+		if d[0] == key {
+			return d[1]
+		}
+	}
+	return ""
+}
+
+// parsePDFDict splits a PDF dictionary into its keys and the source of
+// their values.
+func parsePDFDict(s string) ([][2]string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "<<") || !strings.HasSuffix(s, ">>") {
+		return nil, fmt.Errorf("not a dictionary")
+	}
+	s = s[2 : len(s)-2]
+
+	var entries [][2]string
+	for {
+		s = strings.TrimLeft(s, " \t\r\n\f\x00")
+		if s == "" {
+			return entries, nil
+		}
+		if s[0] != '/' {
+			return nil, fmt.Errorf("invalid key")
+		}
+
+		n := pdfTokenLen(s)
+		key := s[:n]
+		s = strings.TrimLeft(s[n:], " \t\r\n\f\x00")
+
+		n, err := pdfValueLen(s)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, [2]string{key, s[:n]})
+		s = s[n:]
+	}
+}
+
+// pdfValueLen returns the length of the PDF object at the start of s.
+// Indirect references are returned as a single value.
+func pdfValueLen(s string) (int, error) {
+	if s == "" {
+		return 0, fmt.Errorf("missing value")
+	}
+
+	switch {
+	case strings.HasPrefix(s, "<<"), s[0] == '[':
+		depth := 0
+		for i := 0; i < len(s); i++ {
+			switch {
+			case s[i] == '(':
+				n, err := pdfStringLen(s[i:])
+				if err != nil {
+					return 0, err
+				}
+				i += n - 1
+			case strings.HasPrefix(s[i:], "<<"):
+				depth++
+				i++
+			case strings.HasPrefix(s[i:], ">>"):
+				depth--
+				i++
+			case s[i] == '[':
+				depth++
+			case s[i] == ']':
+				depth--
+			}
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated value")
+	case s[0] == '(':
+		return pdfStringLen(s)
+	case s[0] == '<':
+		i := strings.IndexByte(s, '>')
+		if i < 0 {
+			return 0, fmt.Errorf("unterminated string")
+		}
+		return i + 1, nil
+	}
+
+	n := pdfTokenLen(s)
+	if n == 0 {
+		return 0, fmt.Errorf("invalid value")
+	}
+
+	// Indirect references like "12 0 R".
+	if loc := refRegexp.FindStringIndex(s); loc != nil {
+		if loc[1] == len(s) || isDelimiter(s[loc[1]]) {
+			return loc[1], nil
+		}
+	}
+	return n, nil
+}
+
+// pdfStringLen returns the length of the literal string at the start of s.
+func pdfStringLen(s string) (int, error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated string")
+}
+
+// pdfTokenLen returns the length of the name, number or keyword at the start of s.
+func pdfTokenLen(s string) int {
+	i := 0
+	if i < len(s) && s[i] == '/' {
+		i++
+	}
+	for i < len(s) && !isDelimiter(s[i]) {
+		i++
+	}
+	return i
+}
+
+// mergePDFDict sets the entries of the dictionary. Values of existing
+// dictionaries are merged if the new value is a dictionary, too.
+func mergePDFDict(dict, entries [][2]string) ([][2]string, error) {
+	for _, e := range entries {
+		found := false
+		for i, d := range dict {
+			if d[0] != e[0] {
+				continue
+			}
+			found = true
+
+			if strings.HasPrefix(d[1], "<<") && strings.HasPrefix(e[1], "<<") {
+				old, err := parsePDFDict(d[1])
+				if err != nil {
+					return nil, err
+				}
+				add, err := parsePDFDict(e[1])
+				if err != nil {
+					return nil, err
+				}
+				merged, err := mergePDFDict(old, add)
+				if err != nil {
+					return nil, err
+				}
+				dict[i][1] = formatPDFDict(merged)
+			} else {
+				dict[i][1] = e[1]
+			}
+		}
+		if !found {
+			dict = append(dict, e)
+		}
+	}
+	return dict, nil
+}
+
+// formatPDFDict formats the entries as PDF dictionary.
+func formatPDFDict(dict [][2]string) string {
+	var b strings.Builder
+	b.WriteString("<<")
+	for _, d := range dict {
+		b.WriteString(" ")
+		b.WriteString(d[0])
+		b.WriteString(" ")
+		b.WriteString(d[1])
+	}
+	b.WriteString(" >>")
+	return b.String()
+}
@@ -0,0 +1,78 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// ClearFields blanks the values of the specified fields of a filled PDF file.
+// All filled fields are cleared if no field names are passed.
+// Check boxes and radio buttons are switched off.
+// The default values (/DV) of the fields are not modified, because pdftk
+// fill_form only sets the values (/V). Clearing defaults is not supported.
+func ClearFields(pdfFile io.Reader, names ...string) (result io.Reader, err error) {
+	return DefaultFiller.ClearFields(pdfFile, names...)
+}
+
+// ClearFields blanks the values of the specified fields of a filled PDF file.
+// See the package-level ClearFields.
+func (f *Filler) ClearFields(pdfFile io.Reader, names ...string) (result io.Reader, err error) {
+	// The PDF file is read twice.
+	data, err := io.ReadAll(pdfFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the PDF file: %v", err)
+	}
+
+	fields, err := f.ReadFieldsFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]Field, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+
+	if len(names) == 0 {
+		for _, field := range fields {
+			if field.Value != "" {
+				names = append(names, field.Name)
+			}
+		}
+	}
+
+	form := make(Form, len(names))
+	for _, name := range names {
+		field, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("field does not exist: '%s'", name)
+		}
+
+		if field.Type == FieldTypeButton {
+			form[name] = false
+		} else {
+			form[name] = ""
+		}
+	}
+
+	return f.FillFromReaderWithOptions(form, bytes.NewReader(data), Options{})
+}
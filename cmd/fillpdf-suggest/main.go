@@ -0,0 +1,143 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Command fillpdf-suggest suggests a mapping of the keys of sample data to
+// the fields of a form PDF file and writes it as JSON mapping file, which
+// can be adjusted and read with fillpdf.LoadMapping:
+//
+//	fillpdf-suggest -csv data.csv form.pdf > mapping.json
+//	fillpdf-suggest -json data.json -o mapping.json form.pdf
+//	fillpdf-suggest -keys first_name,last_name form.pdf
+//
+// Keys without a similar field are reported on stderr.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/desertbit/fillpdf"
+)
+
+func main() {
+	var (
+		keysFlag = flag.String("keys", "", "comma separated data keys")
+		csvFile  = flag.String("csv", "", "CSV file with the data keys as header")
+		jsonFile = flag.String("json", "", "JSON file with an object of sample data")
+		output   = flag.String("o", "", "output mapping file (default stdout)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] form.pdf\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	keys, err := readKeys(*keysFlag, *csvFile, *jsonFile)
+	if err != nil {
+		log.Fatal(err)
+	} else if len(keys) == 0 {
+		log.Fatal("no data keys: set -keys, -csv or -json")
+	}
+
+	fields, err := fillpdf.ReadFields(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	suggestions := fillpdf.SuggestMapping(keys, fields)
+	mapping := fillpdf.MappingFromSuggestions(suggestions)
+	for _, k := range keys {
+		if _, ok := mapping[k]; !ok {
+			log.Printf("no field found for key '%s'", k)
+		}
+	}
+
+	data, err := json.MarshalIndent(mapping, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readKeys returns the data keys of the flags.
+func readKeys(keysFlag, csvFile, jsonFile string) ([]string, error) {
+	var keys []string
+	if keysFlag != "" {
+		for _, k := range strings.Split(keysFlag, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	if csvFile != "" {
+		f, err := os.Open(csvFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		header, err := csv.NewReader(f).Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CSV header: %v", err)
+		}
+		keys = append(keys, header...)
+	}
+
+	if jsonFile != "" {
+		data, err := os.ReadFile(jsonFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var sample map[string]interface{}
+		err = json.Unmarshal(data, &sample)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the JSON file: %v", err)
+		}
+		jsonKeys := make([]string, 0, len(sample))
+		for k := range sample {
+			jsonKeys = append(jsonKeys, k)
+		}
+		sort.Strings(jsonKeys)
+		keys = append(keys, jsonKeys...)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,116 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldSpec describes a form field which is expected to exist in a template.
+type FieldSpec struct {
+	Name string `json:"name"`
+
+	// Type is the expected field type. Any type is accepted if empty.
+	Type FieldType `json:"type,omitempty"`
+
+	// Options are the state options which must be provided by
+	// buttons and choice fields.
+	Options []string `json:"options,omitempty"`
+}
+
+// CompatibilityError contains all mismatches between a template
+// and the expected fields.
+type CompatibilityError []FieldError
+
+func (e CompatibilityError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "incompatible template: " + strings.Join(msgs, "; ")
+}
+
+// CheckCompatibility validates that the template provides the expected fields,
+// so template revisions can be checked before they replace the current ones.
+// A CompatibilityError is returned if fields are missing or differ.
+func CheckCompatibility(t *Template, expected []FieldSpec) error {
+	fields, err := t.Fields()
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+
+	var errs CompatibilityError
+	for _, spec := range expected {
+		f, ok := byName[spec.Name]
+		if !ok {
+			errs = append(errs, FieldError{Field: spec.Name, Msg: "missing"})
+			continue
+		}
+
+		if spec.Type != "" && spec.Type != f.Type {
+			errs = append(errs, FieldError{
+				Field: spec.Name,
+				Msg:   fmt.Sprintf("type %s expected: got %s", spec.Type, f.Type),
+			})
+		}
+
+		for _, o := range spec.Options {
+			if !containsString(f.Options, o) {
+				errs = append(errs, FieldError{
+					Field: spec.Name,
+					Msg:   fmt.Sprintf("missing option '%s'", o),
+				})
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// FieldSpecs returns the specs of the fields, which can be stored
+// and checked against later template revisions.
+func FieldSpecs(fields []Field) []FieldSpec {
+	specs := make([]FieldSpec, len(fields))
+	for i, f := range fields {
+		specs[i] = FieldSpec{
+			Name:    f.Name,
+			Type:    f.Type,
+			Options: f.Options,
+		}
+	}
+	return specs
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by LoadConfig.
+const (
+	EnvProfile       = "FILLPDF_PROFILE"
+	EnvCommand       = "FILLPDF_COMMAND"
+	EnvTempDir       = "FILLPDF_TEMP_DIR"
+	EnvMaxConcurrent = "FILLPDF_MAX_CONCURRENT"
+	EnvTimeout       = "FILLPDF_TIMEOUT"
+	EnvLog           = "FILLPDF_LOG"
+)
+
+// fileConfig is the JSON configuration file read by LoadConfig.
+// Unset values don't override earlier ones.
+type fileConfig struct {
+	Command       *string               `json:"command"`
+	Args          []string              `json:"args"`
+	TempDir       *string               `json:"tempDir"`
+	MaxConcurrent *int                  `json:"maxConcurrent"`
+	Timeout       *string               `json:"timeout"`
+	Log           *bool                 `json:"log"`
+	Profiles      map[string]fileConfig `json:"profiles"`
+}
+
+// LoadConfig returns the Filler configuration of the environment, so
+// services configure the pdftk command, the temporary directory, limits
+// and logging declaratively. The optional JSON file contains the default
+// settings and a profile per environment, which overrides them:
+//
+//	{
+//		"command": "pdftk",
+//		"timeout": "30s",
+//		"profiles": {
+//			"dev": {"log": true},
+//			"prod": {"maxConcurrent": 8, "tempDir": "/var/tmp/fillpdf"}
+//		}
+//	}
+//
+// The profile is selected by the FILLPDF_PROFILE environment variable.
+// The FILLPDF_COMMAND, FILLPDF_TEMP_DIR, FILLPDF_MAX_CONCURRENT,
+// FILLPDF_TIMEOUT and FILLPDF_LOG environment variables override the file.
+// Logs are written to stderr. Pass an empty path to read only the
+// environment variables. Create the Filler with NewFiller after setting
+// the remaining options, like the default Options.
+func LoadConfig(path string) (FillerConfig, error) {
+	var c FillerConfig
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return c, err
+		}
+
+		var fc fileConfig
+		err = json.Unmarshal(data, &fc)
+		if err != nil {
+			return c, fmt.Errorf("failed to decode the configuration file: %v", err)
+		}
+
+		err = fc.apply(&c)
+		if err != nil {
+			return c, err
+		}
+
+		if name := os.Getenv(EnvProfile); name != "" {
+			profile, ok := fc.Profiles[name]
+			if !ok {
+				return c, fmt.Errorf("configuration profile does not exist: '%s'", name)
+			}
+			err = profile.apply(&c)
+			if err != nil {
+				return c, fmt.Errorf("profile '%s': %v", name, err)
+			}
+		}
+	}
+
+	err := envConfig().apply(&c)
+	if err != nil {
+		return c, fmt.Errorf("environment: %v", err)
+	}
+	return c, nil
+}
+
+// envConfig returns the settings of the environment variables.
+func envConfig() (fc fileConfig) {
+	lookup := func(key string) *string {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			return nil
+		}
+		return &v
+	}
+
+	fc.Command = lookup(EnvCommand)
+	fc.TempDir = lookup(EnvTempDir)
+	fc.Timeout = lookup(EnvTimeout)
+	if v := lookup(EnvMaxConcurrent); v != nil {
+		// Invalid numbers are reported as -1.
+		n, err := strconv.Atoi(strings.TrimSpace(*v))
+		if err != nil {
+			n = -1
+		}
+		fc.MaxConcurrent = &n
+	}
+	if v := lookup(EnvLog); v != nil {
+		b, _ := strconv.ParseBool(*v)
+		fc.Log = &b
+	}
+	return fc
+}
+
+// apply sets the configured settings.
+func (fc fileConfig) apply(c *FillerConfig) error {
+	if fc.Command != nil {
+		c.Command = *fc.Command
+	}
+	if fc.Args != nil {
+		c.Args = fc.Args
+	}
+	if fc.TempDir != nil {
+		c.TempDir = *fc.TempDir
+	}
+	if fc.MaxConcurrent != nil {
+		if *fc.MaxConcurrent < 0 {
+			return fmt.Errorf("invalid maximum number of concurrent processes")
+		}
+		c.MaxConcurrent = *fc.MaxConcurrent
+	}
+	if fc.Timeout != nil {
+		d, err := time.ParseDuration(*fc.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %v", err)
+		}
+		c.Timeout = d
+	}
+	if fc.Log != nil {
+		c.Logger = nil
+		if *fc.Log {
+			c.Logger = log.New(os.Stderr, "fillpdf: ", log.LstdFlags)
+		}
+	}
+	return nil
+}
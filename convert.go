@@ -0,0 +1,74 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Converter converts a mapped value, like an amount in cents to dollars
+// or a weight in kilograms to pounds.
+type Converter func(value interface{}) (interface{}, error)
+
+var (
+	convertersMutex sync.RWMutex
+
+	// converters are the registered converters by their name.
+	converters = make(map[string]Converter)
+)
+
+// RegisterConverter registers the converter with the name for all fills,
+// so field mappings can declare it instead of every call site converting
+// the values. An existing converter with the name is replaced:
+//
+//	fillpdf.RegisterConverter("cents", fillpdf.ScaleConverter(0.01))
+//	fillpdf.RegisterConverter("kg_to_lbs", fillpdf.ScaleConverter(2.20462))
+//
+//	mapping := fillpdf.Mapping{
+//		"amount": {Field: "Amount", Convert: []string{"cents"}, Type: fillpdf.MappingNumber, Format: "$%.2f"},
+//	}
+func RegisterConverter(name string, c Converter) {
+	convertersMutex.Lock()
+	converters[name] = c
+	convertersMutex.Unlock()
+}
+
+// lookupConverter returns the converter with the name.
+func lookupConverter(name string) (Converter, bool) {
+	convertersMutex.RLock()
+	c, ok := converters[name]
+	convertersMutex.RUnlock()
+	return c, ok
+}
+
+// ScaleConverter returns a converter multiplying numbers by the factor,
+// like 0.01 for cents to dollars. Empty values are kept.
+func ScaleConverter(factor float64) Converter {
+	return func(value interface{}) (interface{}, error) {
+		if formatValue(value) == "" {
+			return value, nil
+		}
+		n, ok := toNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid number: '%s'", formatValue(value))
+		}
+		return n * factor, nil
+	}
+}
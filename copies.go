@@ -0,0 +1,97 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CopySeparator separates a field name from the copy number in the form
+// values of FillCopies, like "Name#2".
+const CopySeparator = "#"
+
+// FillCopies fills the form PDF file once per copy and concatenates the
+// filled copies, like labels or tickets repeating a single page.
+// Form values with a copy suffix, like "Name#2", are filled into that copy
+// only. Copies are numbered from one. Values without a suffix are filled
+// into every copy. The copies are always flattened, because the fields of
+// all copies share their names.
+func FillCopies(form Form, formPDFFile string, copies int, opts Options) (io.Reader, error) {
+	return DefaultFiller.FillCopies(form, formPDFFile, copies, opts)
+}
+
+// FillCopies fills the form PDF file once per copy and concatenates the
+// filled copies. See the package-level FillCopies.
+func (f *Filler) FillCopies(form Form, formPDFFile string, copies int, opts Options) (io.Reader, error) {
+	if copies < 1 {
+		return nil, fmt.Errorf("invalid number of copies: %d", copies)
+	}
+
+	forms := make([]Form, copies)
+	for i := range forms {
+		forms[i] = make(Form, len(form))
+	}
+
+	// Values without a suffix are set first, so suffixed values override them.
+	var suffixed []string
+	for k, v := range form {
+		if _, _, ok := splitCopySuffix(k); ok {
+			suffixed = append(suffixed, k)
+			continue
+		}
+		for _, c := range forms {
+			c[k] = v
+		}
+	}
+	for _, k := range suffixed {
+		name, n, _ := splitCopySuffix(k)
+		if n < 1 || n > copies {
+			return nil, fmt.Errorf("field '%s': copy %d does not exist", k, n)
+		}
+		forms[n-1][name] = form[k]
+	}
+
+	opts.Flatten = true
+	r, err := f.FillBatchWithOptions(forms, formPDFFile, BatchOptions{
+		Options:  opts,
+		FailFast: true,
+		Merge:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Merged, nil
+}
+
+// splitCopySuffix splits a field name with a copy suffix, like "Name#2".
+func splitCopySuffix(key string) (name string, n int, ok bool) {
+	i := strings.LastIndex(key, CopySeparator)
+	if i < 0 {
+		return key, 0, false
+	}
+
+	n, err := strconv.Atoi(key[i+len(CopySeparator):])
+	if err != nil {
+		return key, 0, false
+	}
+	return key[:i], n, true
+}
@@ -0,0 +1,192 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Record is a successfully filled record of a batch.
+type Record struct {
+	Index int
+	Form  Form
+
+	// Name is the file name of the record rendered by the Name option
+	// of the batch, or "<index>.pdf".
+	Name string
+
+	// PDF is the filled PDF file.
+	PDF io.Reader
+}
+
+// WebhookDelivery returns a Deliver hook posting every filled PDF file to
+// the URL. The record index and the file name are passed with the
+// X-Record-Index and Content-Disposition headers. Responses with a status
+// other than 2xx fail the record. The client defaults to http.DefaultClient.
+func WebhookDelivery(url string, client *http.Client) func(Record) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(r Record) error {
+		req, err := http.NewRequest(http.MethodPost, url, r.PDF)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/pdf")
+		req.Header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": r.Name}))
+		req.Header.Set("X-Record-Index", strconv.Itoa(r.Index))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to deliver the record: %v", err)
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("failed to deliver the record: %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// SMTPConfig configures the SMTPDelivery hook.
+type SMTPConfig struct {
+	// Addr is the address of the SMTP server, like "mail.example.com:587".
+	Addr string
+
+	// Auth authenticates with the SMTP server, if set.
+	Auth smtp.Auth
+
+	From string
+
+	// To returns the recipients of the record, like its email field.
+	To func(Record) ([]string, error)
+
+	Subject string
+	Body    string
+}
+
+// SMTPDelivery returns a Deliver hook sending every filled PDF file as
+// email attachment with net/smtp.
+func SMTPDelivery(c SMTPConfig) func(Record) error {
+	return func(r Record) error {
+		to, err := c.To(r)
+		if err != nil {
+			return err
+		} else if len(to) == 0 {
+			return fmt.Errorf("record has no recipients")
+		}
+		// The envelope takes the plain addresses, like "a@example.com"
+		// of "Name <a@example.com>".
+		rcpts := make([]string, len(to))
+		for i, addr := range to {
+			a, err := mail.ParseAddress(addr)
+			if err != nil {
+				return fmt.Errorf("invalid recipient '%s': %v", addr, err)
+			}
+			rcpts[i] = a.Address
+		}
+		from, err := mail.ParseAddress(c.From)
+		if err != nil {
+			return fmt.Errorf("invalid sender '%s': %v", c.From, err)
+		}
+
+		msg, err := mailMessage(c, to, r)
+		if err != nil {
+			return err
+		}
+
+		err = smtp.SendMail(c.Addr, c.Auth, from.Address, rcpts, msg)
+		if err != nil {
+			return fmt.Errorf("failed to send the email: %v", err)
+		}
+		return nil
+	}
+}
+
+// mailMessage creates a MIME message with the text body and the PDF file
+// of the record attached.
+func mailMessage(c SMTPConfig, to []string, r Record) ([]byte, error) {
+	data, err := io.ReadAll(r.PDF)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+
+	fmt.Fprintf(&b, "From: %s\r\n", c.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", c.Subject))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	w, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(w, c.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"application/pdf"},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": r.Name})},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines of encoded data must not exceed 76 characters.
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		_, err = io.WriteString(w, encoded[:76]+"\r\n")
+		if err != nil {
+			return nil, err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = io.WriteString(w, encoded+"\r\n")
+	if err != nil {
+		return nil, err
+	}
+
+	err = mw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
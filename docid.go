@@ -0,0 +1,131 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Document information keys of the embedded document ID and content hash.
+const (
+	DocumentIDKey  = "FillPDFDocumentID"
+	ContentHashKey = "FillPDFContentHash"
+)
+
+// newDocumentID returns a random UUID version 4.
+func newDocumentID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// documentInfo returns the pdftk info data of the document ID and the
+// content hash, which is omitted if it is empty.
+func documentInfo(id, hash string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "InfoBegin\nInfoKey: %s\nInfoValue: %s\n", DocumentIDKey, id)
+	if hash != "" {
+		fmt.Fprintf(&b, "InfoBegin\nInfoKey: %s\nInfoValue: %s\n", ContentHashKey, hash)
+	}
+	return b.Bytes()
+}
+
+// fieldsHash returns the HMAC-SHA256 of the sorted field values.
+func fieldsHash(key []byte, fields []Field) string {
+	sorted := append([]Field(nil), fields...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	h := hmac.New(sha256.New, key)
+	for _, field := range sorted {
+		fmt.Fprintf(h, "%q=%q\n", field.Name, field.Value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ReadDocumentID returns the document ID and the content hash embedded
+// with the EmbedDocumentID option. The hash is empty if the fill had no
+// ContentKey option.
+func ReadDocumentID(pdfFile io.Reader) (id, hash string, err error) {
+	return DefaultFiller.ReadDocumentID(pdfFile)
+}
+
+// VerifyContentHash detects modified documents. It returns whether the
+// content hash embedded into the PDF file matches the field values of the
+// file with the key, and whether the field values match the form, which
+// may be nil. Mismatches of the form values are returned like by Verify.
+// Encrypted documents must be decrypted first. Flattened documents have
+// no fields left, so their hash does not cover the printed values.
+func VerifyContentHash(pdfFile io.Reader, form Form, key []byte) (bool, []Mismatch, error) {
+	return DefaultFiller.VerifyContentHash(pdfFile, form, key)
+}
+
+// ReadDocumentID returns the document ID and the content hash
+// embedded with the EmbedDocumentID option.
+func (f *Filler) ReadDocumentID(pdfFile io.Reader) (id, hash string, err error) {
+	info, err := f.readInfo(pdfFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	id, hash = info[DocumentIDKey], info[ContentHashKey]
+	if id == "" {
+		return "", "", fmt.Errorf("PDF file has no document ID")
+	}
+	return id, hash, nil
+}
+
+// VerifyContentHash detects modified documents.
+// See the package-level VerifyContentHash.
+func (f *Filler) VerifyContentHash(pdfFile io.Reader, form Form, key []byte) (bool, []Mismatch, error) {
+	data, err := io.ReadAll(pdfFile)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to read the PDF file: %v", err)
+	}
+
+	_, hash, err := f.ReadDocumentID(bytes.NewReader(data))
+	if err != nil {
+		return false, nil, err
+	} else if hash == "" {
+		return false, nil, fmt.Errorf("PDF file has no content hash")
+	}
+
+	fields, err := f.ReadFieldsFromReader(bytes.NewReader(data))
+	if err != nil {
+		return false, nil, err
+	}
+
+	mismatches := compareFields(fields, form)
+	valid := hmac.Equal([]byte(strings.ToLower(hash)), []byte(fieldsHash(key, fields)))
+	return valid && len(mismatches) == 0, mismatches, nil
+}
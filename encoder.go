@@ -0,0 +1,110 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Encoder converts a form value of a specific type to the text of its field.
+type Encoder func(value interface{}) (string, error)
+
+var (
+	encodersMutex sync.RWMutex
+
+	// encoders are the encoders by the type of the form value.
+	// Integers are formatted exactly, instead of passing them through float64.
+	encoders = map[reflect.Type]Encoder{
+		reflect.TypeOf(int(0)):    func(v interface{}) (string, error) { return strconv.FormatInt(int64(v.(int)), 10), nil },
+		reflect.TypeOf(int8(0)):   func(v interface{}) (string, error) { return strconv.FormatInt(int64(v.(int8)), 10), nil },
+		reflect.TypeOf(int16(0)):  func(v interface{}) (string, error) { return strconv.FormatInt(int64(v.(int16)), 10), nil },
+		reflect.TypeOf(int32(0)):  func(v interface{}) (string, error) { return strconv.FormatInt(int64(v.(int32)), 10), nil },
+		reflect.TypeOf(int64(0)):  func(v interface{}) (string, error) { return strconv.FormatInt(v.(int64), 10), nil },
+		reflect.TypeOf(uint(0)):   func(v interface{}) (string, error) { return strconv.FormatUint(uint64(v.(uint)), 10), nil },
+		reflect.TypeOf(uint8(0)):  func(v interface{}) (string, error) { return strconv.FormatUint(uint64(v.(uint8)), 10), nil },
+		reflect.TypeOf(uint16(0)): func(v interface{}) (string, error) { return strconv.FormatUint(uint64(v.(uint16)), 10), nil },
+		reflect.TypeOf(uint32(0)): func(v interface{}) (string, error) { return strconv.FormatUint(uint64(v.(uint32)), 10), nil },
+		reflect.TypeOf(uint64(0)): func(v interface{}) (string, error) { return strconv.FormatUint(v.(uint64), 10), nil },
+		reflect.TypeOf(float32(0)): func(v interface{}) (string, error) {
+			return strconv.FormatFloat(float64(v.(float32)), 'f', -1, 32), nil
+		},
+		reflect.TypeOf(json.Number("")): func(v interface{}) (string, error) {
+			return string(v.(json.Number)), nil
+		},
+		reflect.TypeOf((*big.Int)(nil)): func(v interface{}) (string, error) {
+			return v.(*big.Int).String(), nil
+		},
+		reflect.TypeOf((*big.Float)(nil)): func(v interface{}) (string, error) {
+			return v.(*big.Float).Text('f', -1), nil
+		},
+		reflect.TypeOf((*big.Rat)(nil)): func(v interface{}) (string, error) {
+			r := v.(*big.Rat)
+			if r.IsInt() {
+				return r.Num().String(), nil
+			}
+			return r.RatString(), nil
+		},
+	}
+)
+
+// RegisterEncoder sets the encoder of form values of the type for all fills,
+// so custom types like money amounts or phone numbers don't have to be
+// converted before every fill. An existing encoder of the type is replaced:
+//
+//	type Cents int64
+//
+//	fillpdf.RegisterEncoder(reflect.TypeOf(Cents(0)), func(v interface{}) (string, error) {
+//		c := v.(Cents)
+//		return fmt.Sprintf("$%d.%02d", c/100, c%100), nil
+//	})
+func RegisterEncoder(t reflect.Type, e Encoder) {
+	encodersMutex.Lock()
+	encoders[t] = e
+	encodersMutex.Unlock()
+}
+
+// lookupEncoder returns the encoder of the type of the value.
+func lookupEncoder(value interface{}) (Encoder, bool) {
+	encodersMutex.RLock()
+	e, ok := encoders[reflect.TypeOf(value)]
+	encodersMutex.RUnlock()
+	return e, ok
+}
+
+// encodeValues converts the form values with a registered encoder to text,
+// so encoder errors fail the fill. The passed form is not modified.
+func encodeValues(form Form) (Form, error) {
+	result := make(Form, len(form))
+	for k, v := range form {
+		if e, ok := lookupEncoder(v); ok {
+			s, err := e(v)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': failed to encode the value: %v", k, err)
+			}
+			v = s
+		}
+		result[k] = v
+	}
+	return result, nil
+}
@@ -0,0 +1,102 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Permission is a permission of the recipients of an encrypted PDF file.
+type Permission string
+
+// Permissions of encrypted PDF files.
+const (
+	PermissionPrinting          Permission = "Printing"
+	PermissionDegradedPrinting  Permission = "DegradedPrinting"
+	PermissionModifyContents    Permission = "ModifyContents"
+	PermissionAssembly          Permission = "Assembly"
+	PermissionCopyContents      Permission = "CopyContents"
+	PermissionScreenReaders     Permission = "ScreenReaders"
+	PermissionModifyAnnotations Permission = "ModifyAnnotations"
+	PermissionFillIn            Permission = "FillIn"
+	PermissionAllFeatures       Permission = "AllFeatures"
+)
+
+// validPermissions are the permissions accepted by pdftk.
+var validPermissions = map[Permission]bool{
+	PermissionPrinting: true, PermissionDegradedPrinting: true, PermissionModifyContents: true,
+	PermissionAssembly: true, PermissionCopyContents: true, PermissionScreenReaders: true,
+	PermissionModifyAnnotations: true, PermissionFillIn: true, PermissionAllFeatures: true,
+}
+
+// encrypt encrypts the PDF file with AES-128 and the user password, which
+// is required to open the file. The owner password is random, so the
+// permissions can't be changed by the recipients. pdftk reads the
+// passwords from stdin instead of its command line arguments, which other
+// local users can read from the process list. Its prompts are written to
+// stdout, therefore the files are passed by path.
+func (f *Filler) encrypt(p Priority, pdfFile []byte, userPassword string, permissions []Permission) ([]byte, error) {
+	if strings.ContainsAny(userPassword, "\r\n") {
+		return nil, fmt.Errorf("invalid user password: contains a line break")
+	}
+
+	if len(permissions) == 0 {
+		permissions = []Permission{PermissionPrinting}
+	}
+	args := []string{"encrypt_aes128", "owner_pw", "PROMPT", "user_pw", "PROMPT", "allow"}
+	for _, perm := range permissions {
+		if !validPermissions[perm] {
+			return nil, fmt.Errorf("invalid permission: '%s'", perm)
+		}
+		args = append(args, string(perm))
+	}
+
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	ownerPassword := hex.EncodeToString(b)
+
+	dir, err := os.MkdirTemp(f.tempDir, "fillpdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in, out := filepath.Join(dir, "in.pdf"), filepath.Join(dir, "out.pdf")
+	err = os.WriteFile(in, pdfFile, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	// pdftk prompts for the owner password first.
+	stdin := strings.NewReader(ownerPassword + "\n" + userPassword + "\n")
+	err = f.run(p, stdin, io.Discard, append([]string{in, "output", out}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(out)
+}
@@ -0,0 +1,142 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// BatchEstimate is the result of a batch dry run.
+type BatchEstimate struct {
+	Records int
+
+	// Invalid are the errors of the records which would fail,
+	// ordered by the record index.
+	Invalid BatchError
+
+	// UnknownFields are the sorted names of form values without a field.
+	// MissingFields are the sorted names of fields without a form value
+	// in any record.
+	UnknownFields []string
+	MissingFields []string
+
+	// Size and Duration are the estimated total size of the filled
+	// PDF files and the duration of the batch. They are extrapolated
+	// from a single sample fill of the first valid record.
+	Size     int64
+	Duration time.Duration
+}
+
+// EstimateBatch validates all records of a batch and estimates the size
+// and duration of the batch without filling every record.
+func EstimateBatch(forms []Form, formPDFFile string, opts BatchOptions) (*BatchEstimate, error) {
+	return DefaultFiller.EstimateBatch(forms, formPDFFile, opts)
+}
+
+// EstimateBatch validates all records of a batch and estimates the size
+// and duration of the batch. See the package-level EstimateBatch.
+func (f *Filler) EstimateBatch(forms []Form, formPDFFile string, opts BatchOptions) (*BatchEstimate, error) {
+	formPDFFile, err := resolveFormFile(formPDFFile)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := f.readFields(formPDFFile)
+	if err != nil {
+		return nil, err
+	}
+	loadFields := func() ([]Field, error) {
+		return fields, nil
+	}
+
+	if opts.Name != "" {
+		_, err = recordNames(forms, opts.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e := &BatchEstimate{Records: len(forms)}
+	unknown := make(map[string]bool)
+	filled := make(map[string]bool)
+
+	recordOpts := opts.Options
+	recordOpts.Validate = true
+
+	sample := -1
+	for i, form := range forms {
+		prepared, _, err := prepareForm(form, recordOpts, loadFields)
+		if err != nil {
+			e.Invalid = append(e.Invalid, RecordError{Index: i, Err: err})
+			continue
+		}
+		if sample < 0 {
+			sample = i
+		}
+
+		u, _ := prepared.Diff(fields)
+		for _, name := range u {
+			unknown[name] = true
+		}
+		for name := range prepared {
+			filled[name] = true
+		}
+	}
+
+	for name := range unknown {
+		e.UnknownFields = append(e.UnknownFields, name)
+	}
+	for _, field := range fields {
+		if !filled[field.Name] {
+			e.MissingFields = append(e.MissingFields, field.Name)
+		}
+	}
+	sort.Strings(e.UnknownFields)
+	sort.Strings(e.MissingFields)
+
+	if sample < 0 {
+		return e, nil
+	}
+
+	// The sample fill is not audited.
+	sampleOpts := opts.Options
+	sampleOpts.OnFill = nil
+	if opts.Password != nil {
+		sampleOpts.UserPassword = opts.Password(sample, forms[sample])
+	}
+
+	start := time.Now()
+	buf := bytes.NewBuffer(nil)
+	err = f.fillFileTo(buf, forms[sample], formPDFFile, sampleOpts, loadFields)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := int64(len(forms) - len(e.Invalid))
+	e.Size = int64(buf.Len()) * valid
+
+	// The records are filled by one process per CPU.
+	rounds := (valid + int64(runtime.NumCPU()) - 1) / int64(runtime.NumCPU())
+	e.Duration = time.Since(start) * time.Duration(rounds)
+
+	return e, nil
+}
@@ -0,0 +1,461 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const exprDateLayout = "2006-01-02"
+
+// Expressions maps field names to expressions computing the field values
+// from other form values, like "Total": "Subtotal + Tax".
+//
+// Expressions support numbers, "quoted" strings, field names, the operators
+// + - * / and parentheses. Field names containing other characters than
+// letters, digits, '_' and '.' are written in brackets: [First Name].
+// The + operator concatenates if one operand is not a number.
+// Strings are numbers only if they parse as numbers, so " " is not.
+// Empty values count as 0 in calculations, like missing amounts, but
+// the sum of two empty values is empty.
+// The following functions are available:
+//
+//	sum(a, b, ...)        sum of the numbers
+//	min(a, b, ...)        smallest number
+//	max(a, b, ...)        largest number
+//	round(x, n)           x rounded to n decimals
+//	fixed(x, n)           x formatted with exactly n decimals
+//	concat(a, b, ...)     concatenation of the values
+//	today()               current date as YYYY-MM-DD
+//	addDays(date, n)      date plus n days
+//	daysBetween(a, b)     number of days from date a to date b
+//
+// An expression can use the results of other expressions.
+type Expressions map[string]string
+
+// Apply evaluates the expressions and returns a new form with the computed values.
+// The passed form is not modified.
+func (e Expressions) Apply(form Form) (Form, error) {
+	result := make(Form, len(form)+len(e))
+	for k, v := range form {
+		result[k] = v
+	}
+
+	ev := &exprEvaluator{
+		exprs:   e,
+		form:    result,
+		pending: make(map[string]bool),
+	}
+	for field := range e {
+		_, err := ev.field(field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// evalExpression evaluates a single expression with the form values.
+// The passed form is not modified.
+func evalExpression(expr string, form Form) (interface{}, error) {
+	ev := &exprEvaluator{
+		form:    form,
+		pending: make(map[string]bool),
+	}
+	p := &exprParser{ev: ev, s: expr}
+	return p.parse()
+}
+
+type exprEvaluator struct {
+	exprs   Expressions
+	form    Form
+	pending map[string]bool
+	done    map[string]bool
+}
+
+// field returns the value of a field and evaluates its expression if required.
+func (ev *exprEvaluator) field(name string) (interface{}, error) {
+	expr, ok := ev.exprs[name]
+	if !ok || ev.done[name] {
+		v := ev.form[name]
+		if v == nil {
+			return "", nil
+		}
+		return v, nil
+	}
+
+	if ev.pending[name] {
+		return nil, fmt.Errorf("expression '%s': circular reference", name)
+	}
+	ev.pending[name] = true
+
+	p := &exprParser{ev: ev, s: expr}
+	v, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("expression '%s': %v", name, err)
+	}
+
+	ev.form[name] = v
+	if ev.done == nil {
+		ev.done = make(map[string]bool)
+	}
+	ev.done[name] = true
+	delete(ev.pending, name)
+
+	return v, nil
+}
+
+// exprParser is a recursive descent parser evaluating an expression.
+type exprParser struct {
+	ev  *exprEvaluator
+	s   string
+	pos int
+}
+
+func (p *exprParser) parse() (interface{}, error) {
+	v, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected '%c' at position %d", p.s[p.pos], p.pos)
+	}
+	return v, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character or 0.
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("expected '%c' at position %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *exprParser) expr() (interface{}, error) {
+	v, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return v, nil
+		}
+		p.pos++
+
+		w, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		a, aok := toNumber(v)
+		b, bok := toNumber(w)
+		if op == '+' && formatValue(v) == "" && formatValue(w) == "" {
+			v = ""
+			continue
+		} else if op == '+' && (!aok || !bok) {
+			v = formatValue(v) + formatValue(w)
+			continue
+		} else if !aok || !bok {
+			return nil, fmt.Errorf("operator '-' requires numbers")
+		}
+
+		if op == '+' {
+			v = a + b
+		} else {
+			v = a - b
+		}
+	}
+}
+
+func (p *exprParser) term() (interface{}, error) {
+	v, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return v, nil
+		}
+		p.pos++
+
+		w, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		a, aok := toNumber(v)
+		b, bok := toNumber(w)
+		if !aok || !bok {
+			return nil, fmt.Errorf("operator '%c' requires numbers", op)
+		}
+
+		if op == '*' {
+			v = a * b
+		} else if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		} else {
+			v = a / b
+		}
+	}
+}
+
+func (p *exprParser) unary() (interface{}, error) {
+	if p.peek() != '-' {
+		return p.primary()
+	}
+	p.pos++
+
+	v, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	n, ok := toNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("operator '-' requires a number")
+	}
+	return -n, nil
+}
+
+func (p *exprParser) primary() (interface{}, error) {
+	c := p.peek()
+
+	switch {
+	case c == '(':
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return v, p.expect(')')
+
+	case c == '"':
+		p.pos++
+		end := strings.IndexByte(p.s[p.pos:], '"')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		v := p.s[p.pos : p.pos+end]
+		p.pos += end + 1
+		return v, nil
+
+	case c == '[':
+		p.pos++
+		end := strings.IndexByte(p.s[p.pos:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated field name")
+		}
+		name := p.s[p.pos : p.pos+end]
+		p.pos += end + 1
+		return p.ev.field(name)
+
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.s) && (p.s[p.pos] >= '0' && p.s[p.pos] <= '9' || p.s[p.pos] == '.') {
+			p.pos++
+		}
+		return strconv.ParseFloat(p.s[start:p.pos], 64)
+
+	case isIdentChar(c):
+		start := p.pos
+		for p.pos < len(p.s) && isIdentChar(p.s[p.pos]) {
+			p.pos++
+		}
+		name := p.s[start:p.pos]
+
+		if p.peek() != '(' {
+			return p.ev.field(name)
+		}
+		p.pos++
+
+		var args []interface{}
+		for p.peek() != ')' {
+			if len(args) > 0 {
+				if err := p.expect(','); err != nil {
+					return nil, err
+				}
+			}
+			v, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		}
+		p.pos++
+
+		return callExprFunc(name, args)
+
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+
+	default:
+		return nil, fmt.Errorf("unexpected '%c' at position %d", c, p.pos)
+	}
+}
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
+
+// callExprFunc calls the expression function with the arguments.
+func callExprFunc(name string, args []interface{}) (interface{}, error) {
+	numbers := func() ([]float64, error) {
+		ns := make([]float64, len(args))
+		for i, a := range args {
+			n, ok := toNumber(a)
+			if !ok {
+				return nil, fmt.Errorf("%s: argument %d is not a number", name, i+1)
+			}
+			ns[i] = n
+		}
+		return ns, nil
+	}
+	argCount := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%s: expected %d arguments", name, n)
+		}
+		return nil
+	}
+
+	switch name {
+	case "sum", "min", "max":
+		ns, err := numbers()
+		if err != nil {
+			return nil, err
+		} else if len(ns) == 0 {
+			return 0.0, nil
+		}
+		r := ns[0]
+		for _, n := range ns[1:] {
+			switch name {
+			case "sum":
+				r += n
+			case "min":
+				r = math.Min(r, n)
+			case "max":
+				r = math.Max(r, n)
+			}
+		}
+		return r, nil
+
+	case "round", "fixed":
+		if err := argCount(2); err != nil {
+			return nil, err
+		}
+		ns, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		if name == "fixed" {
+			return strconv.FormatFloat(ns[0], 'f', int(ns[1]), 64), nil
+		}
+		p := math.Pow(10, ns[1])
+		return math.Round(ns[0]*p) / p, nil
+
+	case "concat":
+		var b strings.Builder
+		for _, a := range args {
+			b.WriteString(formatValue(a))
+		}
+		return b.String(), nil
+
+	case "today":
+		if err := argCount(0); err != nil {
+			return nil, err
+		}
+		return time.Now().Format(exprDateLayout), nil
+
+	case "addDays":
+		if err := argCount(2); err != nil {
+			return nil, err
+		}
+		d, err := time.Parse(exprDateLayout, formatValue(args[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid date: %v", name, err)
+		}
+		n, ok := toNumber(args[1])
+		if !ok {
+			return nil, fmt.Errorf("%s: argument 2 is not a number", name)
+		}
+		return d.AddDate(0, 0, int(n)).Format(exprDateLayout), nil
+
+	case "daysBetween":
+		if err := argCount(2); err != nil {
+			return nil, err
+		}
+		a, err := time.Parse(exprDateLayout, formatValue(args[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid date: %v", name, err)
+		}
+		b, err := time.Parse(exprDateLayout, formatValue(args[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid date: %v", name, err)
+		}
+		return math.Round(b.Sub(a).Hours() / 24), nil
+
+	default:
+		return nil, fmt.Errorf("unknown function '%s'", name)
+	}
+}
+
+// toNumber converts a value to a number if possible.
+// Empty strings are treated as zero. Other strings are numbers only if
+// they parse as numbers after trimming surrounding spaces, so strings
+// consisting of spaces are not.
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		if n == "" {
+			return 0, true
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	default:
+		f, err := strconv.ParseFloat(formatValue(v), 64)
+		return f, err == nil
+	}
+}
@@ -0,0 +1,187 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// parseLiteralString parses the content of a PDF literal string up to the
+// closing parenthesis. It returns the unescaped bytes and the remaining data.
+func parseLiteralString(b []byte) ([]byte, []byte, error) {
+	var s []byte
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return s, b[i+1:], nil
+			}
+			depth--
+		case '\r', '\n':
+			return nil, nil, fmt.Errorf("unescaped line break at %d", i)
+		case '\\':
+			i++
+			if i == len(b) {
+				return nil, nil, fmt.Errorf("unterminated escape sequence")
+			}
+			c = b[i]
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '(', ')', '\\':
+			default:
+				if c < '0' || c > '7' {
+					return nil, nil, fmt.Errorf("invalid escape sequence '\\%c'", c)
+				}
+				n := 0
+				for j := 0; j < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7'; j++ {
+					n = n*8 + int(b[i]-'0')
+					i++
+				}
+				i--
+				c = byte(n)
+			}
+		}
+		s = append(s, c)
+	}
+	return nil, nil, fmt.Errorf("unterminated literal string")
+}
+
+// decodeUTF16 decodes the big endian UTF-16 bytes with a byte order mark.
+func decodeUTF16(b []byte) (string, error) {
+	if len(b) < 2 || b[0] != 254 || b[1] != 255 {
+		return "", fmt.Errorf("missing byte order mark")
+	} else if len(b)%2 != 0 {
+		return "", fmt.Errorf("odd number of bytes")
+	}
+	u := make([]uint16, 0, len(b)/2-1)
+	for i := 2; i < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+// parseFdfField parses the single field of the FDF data of createFdfFile.
+func parseFdfField(data []byte) (key, value string, err error) {
+	rest, ok := bytes.CutPrefix(data, []byte(fdfHeader+"\n<< /T ("))
+	if !ok {
+		return "", "", fmt.Errorf("invalid header")
+	}
+	k, rest, err := parseLiteralString(rest)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid field name: %v", err)
+	}
+	rest, ok = bytes.CutPrefix(rest, []byte(" /V ("))
+	if !ok {
+		return "", "", fmt.Errorf("missing value after field name")
+	}
+	v, rest, err := parseLiteralString(rest)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid field value: %v", err)
+	}
+	if !bytes.Equal(rest, []byte(">>\n"+fdfFooter+"\n")) {
+		return "", "", fmt.Errorf("unexpected data after the field: %q", rest)
+	}
+	value, err = decodeUTF16(v)
+	if err != nil {
+		return "", "", err
+	}
+	return string(k), value, nil
+}
+
+func TestCreateFdfFile(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"name", "John"},
+		{"a(b)c", `x) >> << /T (injected) /V (y`},
+		{"back\\slash", "line\nbreak\r\n"},
+		{"emoji", "a😀b"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		bp := createFdfFile(Form{tt.key: tt.value}, Options{})
+		key, value, err := parseFdfField(*bp)
+		releaseFdfFile(bp)
+		if err != nil {
+			t.Errorf("%q: %v", tt.key, err)
+		} else if key != tt.key || value != tt.value {
+			t.Errorf("got %q=%q, want %q=%q", key, value, tt.key, tt.value)
+		}
+	}
+}
+
+func FuzzCreateFdfFile(f *testing.F) {
+	f.Add("name", "John")
+	f.Add("a)b", "c(d\\e")
+	f.Add("x\r\ny", "😀)\n")
+	f.Fuzz(func(t *testing.T, key, value string) {
+		bp := createFdfFile(Form{key: value}, Options{})
+		defer releaseFdfFile(bp)
+
+		k, v, err := parseFdfField(*bp)
+		if err != nil {
+			t.Fatalf("%q=%q: %v", key, value, err)
+		}
+		if k != key {
+			t.Errorf("got field name %q, want %q", k, key)
+		}
+		// Invalid UTF-8 bytes are written as replacement characters.
+		if utf8.ValidString(value) && v != value {
+			t.Errorf("got value %q, want %q", v, value)
+		}
+	})
+}
+
+func BenchmarkCreateFdfFile(b *testing.B) {
+	for _, n := range []int{10, 1000} {
+		form := benchmarkForm(n)
+		opts := Options{ReadOnly: true}
+
+		b.Run(fmt.Sprintf("fields=%d/pooled", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				releaseFdfFile(createFdfFile(form, opts))
+			}
+		})
+		// Buffers which are not released show the cost without pooling.
+		b.Run(fmt.Sprintf("fields=%d/unpooled", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				createFdfFile(form, opts)
+			}
+		})
+	}
+}
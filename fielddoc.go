@@ -0,0 +1,130 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fieldDocColumns are the columns of the field inventory.
+var fieldDocColumns = []string{"Name", "Type", "Options", "Required", "Max Length", "Pages"}
+
+// FieldsCSV returns the inventory of the form fields of the template as CSV
+// file with the columns name, type, options, required, max length and pages,
+// which can be handed to analysts defining the data mapping.
+func (t *Template) FieldsCSV() ([]byte, error) {
+	rows, err := t.fieldDocRows()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	err = w.WriteAll(append([][]string{fieldDocColumns}, rows...))
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// FieldsMarkdown returns the inventory of the form fields of the template
+// as Markdown table. See FieldsCSV.
+func (t *Template) FieldsMarkdown() ([]byte, error) {
+	rows, err := t.fieldDocRows()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			// Pipes and line breaks would break the table.
+			c = strings.ReplaceAll(c, "|", `\|`)
+			c = strings.Join(strings.Fields(c), " ")
+			b.WriteString(" " + c + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(fieldDocColumns)
+	b.WriteString(strings.Repeat("| --- ", len(fieldDocColumns)) + "|\n")
+	for _, r := range rows {
+		writeRow(r)
+	}
+	return b.Bytes(), nil
+}
+
+// fieldDocRows returns the rows of the field inventory in the order
+// of the fields.
+func (t *Template) fieldDocRows() ([][]string, error) {
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	widgets, err := t.Widgets()
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string][]int)
+	for _, w := range widgets {
+		p := pages[w.Field]
+		if len(p) == 0 || p[len(p)-1] != w.Page {
+			pages[w.Field] = append(p, w.Page)
+		}
+	}
+
+	rows := make([][]string, 0, len(fields))
+	for _, f := range fields {
+		var options []string
+		for _, o := range f.Options {
+			if o != "Off" {
+				options = append(options, o)
+			}
+		}
+
+		var maxLength string
+		if f.MaxLength > 0 {
+			maxLength = strconv.Itoa(f.MaxLength)
+		}
+
+		p := pages[f.Name]
+		sort.Ints(p)
+		pageNumbers := make([]string, len(p))
+		for i, n := range p {
+			pageNumbers[i] = strconv.Itoa(n)
+		}
+
+		rows = append(rows, []string{
+			f.Name,
+			string(f.Type),
+			strings.Join(options, ", "),
+			strconv.FormatBool(f.IsRequired()),
+			maxLength,
+			strings.Join(pageNumbers, ", "),
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,142 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Layout of the field map labels (points).
+const (
+	fieldMapFontSize = 6
+	fieldMapPadding  = 1
+)
+
+// FieldMap returns the form PDF file with every field widget outlined and
+// labeled with the field name and type, which helps mapping data to large
+// forms.
+func FieldMap(formPDFFile string) (io.Reader, error) {
+	return DefaultFiller.FieldMap(formPDFFile)
+}
+
+// FieldMap returns the form PDF file with every field outlined and labeled.
+// See the package-level FieldMap.
+func (f *Filler) FieldMap(formPDFFile string) (io.Reader, error) {
+	t, err := f.NewTemplate(formPDFFile)
+	if err != nil {
+		return nil, err
+	}
+	return t.FieldMap()
+}
+
+// FieldMap returns the template with every field outlined and labeled.
+// See the package-level FieldMap.
+func (t *Template) FieldMap() (io.Reader, error) {
+	data, err := t.readData()
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	widgets, pages, err := t.filler.readWidgets(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(t.filler.tempDir, "fillpdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = tmp.Write(renderFieldMapPDF(fields, widgets, pages))
+	if err != nil {
+		return nil, err
+	}
+
+	// Every page of the template is stamped with the page of the overlay.
+	out, err := t.filler.output(PriorityNormal, bytes.NewReader(data), "-", "multistamp", tmp.Name(), "output", "-")
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
+
+// renderFieldMapPDF renders an overlay page of the same size for every
+// page with the outlined and labeled widgets.
+func renderFieldMapPDF(fields []Field, widgets []Widget, pages []Rect) []byte {
+	types := make(map[string]FieldType, len(fields))
+	for _, field := range fields {
+		types[field.Name] = field.Type
+	}
+
+	contents := make([]strings.Builder, len(pages))
+	for _, w := range widgets {
+		if w.Page < 1 || w.Page > len(pages) {
+			continue
+		}
+		c := &contents[w.Page-1]
+		r := w.Rect
+
+		// The label is placed above the widget, or inside it at the top of the page.
+		y := r.Y2 + fieldMapPadding
+		if y+fieldMapFontSize > pages[w.Page-1].Y2 {
+			y = r.Y2 - fieldMapFontSize - fieldMapPadding
+		}
+
+		label := w.Field
+		if t, ok := types[w.Field]; ok {
+			label += " [" + string(t) + "]"
+		}
+
+		fmt.Fprintf(c, "1 0 0 RG 0.5 w %.2f %.2f %.2f %.2f re S\n", r.X1, r.Y1, r.Width(), r.Height())
+		fmt.Fprintf(c, "BT 1 0 0 rg /F1 %d Tf %.2f %.2f Td (%s) Tj ET\n", fieldMapFontSize, r.X1, y, escapeTextPDF(label))
+	}
+
+	// Objects: 1 catalog, 2 pages, 3 font, then page and content pairs.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	for i, box := range pages {
+		content := contents[i].String()
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [%.2f %.2f %.2f %.2f] "+
+				"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				box.X1, box.Y1, box.X2, box.Y2, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	return writePDFObjects(objects)
+}
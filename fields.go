@@ -0,0 +1,231 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FieldType defines the type of a form field.
+type FieldType string
+
+const (
+	FieldTypeText      FieldType = "Text"
+	FieldTypeButton    FieldType = "Button"
+	FieldTypeChoice    FieldType = "Choice"
+	FieldTypeSignature FieldType = "Signature"
+)
+
+// Field flags as defined by the PDF specification.
+const (
+	FieldFlagReadOnly    = 1 << 0
+	FieldFlagRequired    = 1 << 1
+	FieldFlagNoExport    = 1 << 2
+	FieldFlagMultiline   = 1 << 12
+	FieldFlagPassword    = 1 << 13
+	FieldFlagRadio       = 1 << 15
+	FieldFlagPushbutton  = 1 << 16
+	FieldFlagCombo       = 1 << 17
+	FieldFlagEdit        = 1 << 18
+	FieldFlagMultiSelect = 1 << 21
+	FieldFlagComb        = 1 << 24
+)
+
+// Annotation flags of the field widgets as defined by the PDF specification.
+const (
+	AnnotFlagInvisible = 1 << 0
+	AnnotFlagHidden    = 1 << 1
+	AnnotFlagPrint     = 1 << 2
+	AnnotFlagNoView    = 1 << 5
+	AnnotFlagReadOnly  = 1 << 6
+	AnnotFlagLocked    = 1 << 7
+)
+
+// FieldFlags defines the flags to set and clear on a field in the output.
+// Field flags are FieldFlag* values and annotation flags are AnnotFlag* values.
+type FieldFlags struct {
+	Set        int
+	Clear      int
+	SetAnnot   int
+	ClearAnnot int
+}
+
+// appendFdf appends the FDF field dictionary entries of the flags to the slice.
+func (f FieldFlags) appendFdf(b []byte) []byte {
+	if f.Set != 0 {
+		b = append(b, " /SetFf "...)
+		b = strconv.AppendInt(b, int64(f.Set), 10)
+	}
+	if f.Clear != 0 {
+		b = append(b, " /ClrFf "...)
+		b = strconv.AppendInt(b, int64(f.Clear), 10)
+	}
+	if f.SetAnnot != 0 {
+		b = append(b, " /SetF "...)
+		b = strconv.AppendInt(b, int64(f.SetAnnot), 10)
+	}
+	if f.ClearAnnot != 0 {
+		b = append(b, " /ClrF "...)
+		b = strconv.AppendInt(b, int64(f.ClearAnnot), 10)
+	}
+	return b
+}
+
+// Field describes a form field of a PDF file.
+type Field struct {
+	Name          string
+	AltName       string
+	Type          FieldType
+	Flags         int
+	Value         string
+	DefaultValue  string
+	Justification string
+	MaxLength     int
+
+	// Options contains the state options of buttons and choice fields.
+	Options []string
+}
+
+// IsCheckbox returns whether the field is a check box.
+func (f Field) IsCheckbox() bool {
+	return f.Type == FieldTypeButton && f.Flags&(FieldFlagRadio|FieldFlagPushbutton) == 0
+}
+
+// IsRadio returns whether the field is a radio button group.
+func (f Field) IsRadio() bool {
+	return f.Type == FieldTypeButton && f.Flags&FieldFlagRadio != 0
+}
+
+// IsPushbutton returns whether the field is a push button.
+func (f Field) IsPushbutton() bool {
+	return f.Type == FieldTypeButton && f.Flags&FieldFlagPushbutton != 0
+}
+
+// IsComb returns whether the field is a comb field, which prints
+// its MaxLength characters in equally spaced boxes.
+func (f Field) IsComb() bool {
+	return f.Type == FieldTypeText && f.Flags&FieldFlagComb != 0 && f.MaxLength > 0
+}
+
+// IsRequired returns whether the field is required.
+func (f Field) IsRequired() bool {
+	return f.Flags&FieldFlagRequired != 0
+}
+
+// IsReadOnly returns whether the field is read-only.
+func (f Field) IsReadOnly() bool {
+	return f.Flags&FieldFlagReadOnly != 0
+}
+
+// ReadFields returns the form fields of the form PDF file.
+func ReadFields(formPDFFile string) ([]Field, error) {
+	return DefaultFiller.ReadFields(formPDFFile)
+}
+
+// ReadFieldsFromReader returns the form fields of the PDF file.
+func ReadFieldsFromReader(pdfFile io.Reader) ([]Field, error) {
+	return DefaultFiller.ReadFieldsFromReader(pdfFile)
+}
+
+// ReadFields returns the form fields of the form PDF file.
+func (f *Filler) ReadFields(formPDFFile string) ([]Field, error) {
+	formPDFFile, err := resolveFormFile(formPDFFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.readFields(formPDFFile)
+}
+
+// ReadFieldsFromReader returns the form fields of the PDF file.
+func (f *Filler) ReadFieldsFromReader(pdfFile io.Reader) ([]Field, error) {
+	out, err := f.output(PriorityNormal, pdfFile, "-", "dump_data_fields_utf8", "output", "-")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFields(strings.NewReader(string(out)))
+}
+
+// readFields returns the form fields of the form PDF file located at the path.
+func (f *Filler) readFields(formPDFFile string) ([]Field, error) {
+	out, err := f.output(PriorityNormal, nil, safePath(formPDFFile), "dump_data_fields_utf8", "output", "-")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFields(strings.NewReader(string(out)))
+}
+
+// parseFields parses the output of the pdftk dump_data_fields operation.
+func parseFields(r io.Reader) ([]Field, error) {
+	var (
+		fields []Field
+		f      *Field
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "---" {
+			fields = append(fields, Field{})
+			f = &fields[len(fields)-1]
+			continue
+		} else if f == nil {
+			continue
+		}
+
+		pos := strings.Index(line, ": ")
+		if pos < 0 {
+			continue
+		}
+		key, value := line[:pos], line[pos+2:]
+
+		switch key {
+		case "FieldType":
+			f.Type = FieldType(value)
+		case "FieldName":
+			f.Name = value
+		case "FieldNameAlt":
+			f.AltName = value
+		case "FieldFlags":
+			f.Flags, _ = strconv.Atoi(value)
+		case "FieldValue":
+			f.Value = value
+		case "FieldValueDefault":
+			f.DefaultValue = value
+		case "FieldJustification":
+			f.Justification = value
+		case "FieldMaxLength":
+			f.MaxLength, _ = strconv.Atoi(value)
+		case "FieldStateOption":
+			f.Options = append(f.Options, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse the form fields: %v", err)
+	}
+
+	return fields, nil
+}
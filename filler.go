@@ -0,0 +1,223 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned for fills started after the filler has been closed.
+var ErrClosed = errors.New("filler is closed")
+
+// FillerConfig configures a Filler.
+type FillerConfig struct {
+	// Command is the name of the pdftk command.
+	// Defaults to "pdftk".
+	Command string
+
+	// Args are prepended to the arguments of every pdftk call.
+	Args []string
+
+	// TempDir is the directory of the temporary files passed to pdftk.
+	// Defaults to os.TempDir.
+	TempDir string
+
+	// Options are the default options used by Fill and FillFromReader.
+	Options Options
+
+	// Logger logs every pdftk call and its duration, if set.
+	Logger *log.Logger
+
+	// MaxConcurrent limits the number of concurrently running
+	// pdftk processes. Zero means no limit. Waiting fills are
+	// started in the order of their Priority option.
+	MaxConcurrent int
+
+	// Timeout kills a pdftk process running longer than the duration.
+	// Zero means no timeout.
+	Timeout time.Duration
+
+	// Limiter enforces per-tenant quotas, if set.
+	Limiter *TenantLimiter
+
+	// Jobs persists the jobs started by SubmitFill.
+	// Defaults to a MemoryJobStore.
+	Jobs JobStore
+
+	// AuditKey is the secret key of the hashes of the HashAuditValues
+	// option. Without a key, no hashes are added.
+	AuditKey []byte
+}
+
+// Filler fills PDF forms with its own pdftk command, default options,
+// logger and limits. A Filler is safe for concurrent use.
+// The top-level functions of this package use the DefaultFiller.
+type Filler struct {
+	opts    Options
+	tempDir string
+	logger  *log.Logger
+	timeout time.Duration
+	sched   *scheduler
+	limiter *TenantLimiter
+	jobs    JobStore
+
+	// auditKey is the key of the audit value hashes.
+	auditKey []byte
+
+	mutex sync.RWMutex
+	name  string
+	args  []string
+
+	// path caches the resolved executable path of the command.
+	path string
+
+	// ctx is canceled to kill the running pdftk processes.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  bool
+	running sync.WaitGroup
+
+	// fills is the number of fills executing pdftk calls.
+	fills int
+}
+
+// DefaultFiller is used by the top-level functions of this package.
+var DefaultFiller = NewFiller(FillerConfig{})
+
+// NewFiller creates a new Filler with the configuration.
+func NewFiller(c FillerConfig) *Filler {
+	f := &Filler{
+		opts:     c.Options,
+		tempDir:  c.TempDir,
+		logger:   c.Logger,
+		timeout:  c.Timeout,
+		limiter:  c.Limiter,
+		jobs:     c.Jobs,
+		auditKey: append([]byte(nil), c.AuditKey...),
+		name:     c.Command,
+		args:     append([]string(nil), c.Args...),
+	}
+	if f.name == "" {
+		f.name = "pdftk"
+	}
+	if f.jobs == nil {
+		f.jobs = NewMemoryJobStore()
+	}
+	if c.MaxConcurrent > 0 {
+		f.sched = newScheduler(c.MaxConcurrent)
+	}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+	return f
+}
+
+// Close stops the filler from starting new pdftk processes and waits for
+// the running ones and the fills in progress to finish, including their
+// post-processing like appendices and encryption. Processes still running
+// when the context is done are killed. Fills started after Close fail
+// with ErrClosed.
+// The error of the context is returned if processes had to be killed.
+func (f *Filler) Close(ctx context.Context) error {
+	f.mutex.Lock()
+	f.closed = true
+	f.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		f.cancel()
+		return nil
+	case <-ctx.Done():
+		f.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// acquireQuota starts a fill of the tenant of the options.
+// The returned function must be called with the size of the filled PDF file.
+func (f *Filler) acquireQuota(opts Options) (release func(n int64), err error) {
+	tenant := opts.Tenant
+	if f.limiter == nil || tenant == "" {
+		return func(int64) {}, nil
+	}
+
+	err = f.limiter.acquire(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return func(n int64) {
+		f.limiter.release(tenant, n)
+	}, nil
+}
+
+// begin registers a new pdftk process.
+// It must be followed by a call to end.
+// Processes of fills in progress are started after Close, so the
+// fills are drained.
+func (f *Filler) begin() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed && f.fills == 0 {
+		return ErrClosed
+	}
+	f.running.Add(1)
+	return nil
+}
+
+// end unregisters a finished pdftk process.
+func (f *Filler) end() {
+	f.running.Done()
+}
+
+// beginFill registers a fill, which runs several pdftk processes.
+// It must be followed by a call to endFill.
+func (f *Filler) beginFill() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+	f.fills++
+	f.running.Add(1)
+	return nil
+}
+
+// endFill unregisters a finished fill.
+func (f *Filler) endFill() {
+	f.mutex.Lock()
+	f.fills--
+	f.mutex.Unlock()
+	f.running.Done()
+}
+
+// Options returns the default options of the filler.
+func (f *Filler) Options() Options {
+	return f.opts
+}
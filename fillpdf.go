@@ -20,120 +20,617 @@ package fillpdf
 
 import (
 	"bytes"
-	"encoding/binary"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 	"unicode/utf16"
 )
 
 // Form represents the PDF form.
 // This is a key value map.
+// The key is the fully qualified field name, like "Client.Name".
+// All widgets of a field share the field value, so a field repeated
+// on several pages shows the same value on every page.
 type Form map[string]interface{}
 
 // FillFromReader fills a PDF form with the specified form values and creates a final filled PDF file.
 func FillFromReader(form Form, pdfFile io.Reader) (result io.Reader, err error) {
-	// Check if the pdftk utility exists.
-	_, err = exec.LookPath("pdftk")
+	return DefaultFiller.FillFromReader(form, pdfFile)
+}
+
+// FillFromReaderWithOptions fills a PDF form with the specified form values and options
+// and creates a final filled PDF file.
+func FillFromReaderWithOptions(form Form, pdfFile io.Reader, opts Options) (result io.Reader, err error) {
+	return DefaultFiller.FillFromReaderWithOptions(form, pdfFile, opts)
+}
+
+// Fill fills a PDF form with the specified form values and creates a final filled PDF file.
+func Fill(form Form, formPDFFile string) (result io.Reader, err error) {
+	return DefaultFiller.Fill(form, formPDFFile)
+}
+
+// FillWithOptions fills a PDF form with the specified form values and options
+// and creates a final filled PDF file.
+func FillWithOptions(form Form, formPDFFile string, opts Options) (result io.Reader, err error) {
+	return DefaultFiller.FillWithOptions(form, formPDFFile, opts)
+}
+
+// FillFromReader fills a PDF form with the specified form values and the default
+// options of the filler and creates a final filled PDF file.
+func (f *Filler) FillFromReader(form Form, pdfFile io.Reader) (result io.Reader, err error) {
+	return f.FillFromReaderWithOptions(form, pdfFile, f.opts)
+}
+
+// FillFromReaderWithOptions fills a PDF form with the specified form values and options
+// and creates a final filled PDF file.
+func (f *Filler) FillFromReaderWithOptions(form Form, pdfFile io.Reader, opts Options) (result io.Reader, err error) {
+	return f.fillReader(form, pdfFile, opts, nil)
+}
+
+// fillReader fills the PDF file read from the reader.
+// The fields are loaded from the PDF file if no loader is passed
+// and the options require them.
+func (f *Filler) fillReader(form Form, pdfFile io.Reader, opts Options, fields fieldsLoader) (result io.Reader, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = f.fillTo(buf, form, "", pdfFile, opts, fields)
 	if err != nil {
-		return nil, fmt.Errorf("pdftk utility is not installed!")
+		return nil, err
+	}
+
+	return bytes.NewReader(buf.Bytes()), nil
+}
+
+// Fill fills a PDF form with the specified form values and the default
+// options of the filler and creates a final filled PDF file.
+func (f *Filler) Fill(form Form, formPDFFile string) (result io.Reader, err error) {
+	return f.FillWithOptions(form, formPDFFile, f.opts)
+}
+
+// FillWithOptions fills a PDF form with the specified form values and options
+// and creates a final filled PDF file.
+func (f *Filler) FillWithOptions(form Form, formPDFFile string, opts Options) (result io.Reader, err error) {
+	if !opts.Fast {
+		formPDFFile, err = resolveFormFile(formPDFFile)
+		if err != nil {
+			return nil, err
+		}
 	}
-	fdfFile := createFdfFile(form)
-	f, err := os.CreateTemp("", "fdf")
+
+	return f.fillFile(form, formPDFFile, opts, func() ([]Field, error) {
+		return f.readFields(formPDFFile)
+	})
+}
+
+// resolveFormFile returns the absolute path of the form PDF file and
+// checks if it exists.
+func resolveFormFile(formPDFFile string) (string, error) {
+	// Get the absolute paths.
+	formPDFFile, err := filepath.Abs(formPDFFile)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to create the absolute path: %v", err)
 	}
-	defer os.Remove(f.Name())
-	_, err = f.Write(fdfFile)
+
+	// Check if the form file exists.
+	e, err := exists(formPDFFile)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to check if form PDF file exists: %v", err)
+	} else if !e {
+		return "", fmt.Errorf("form PDF file does not exist: '%s'", formPDFFile)
 	}
-	args := []string{
-		"-",
-		"fill_form", f.Name(),
-		"output", "-",
+
+	return formPDFFile, nil
+}
+
+// verifyChecksum returns ErrTemplateModified if the hex encoded SHA-256
+// checksum does not match the data.
+func verifyChecksum(data []byte, checksum string) error {
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+		return ErrTemplateModified
 	}
-	cmd := exec.Command("pdftk", args...)
-	cmd.Stdin = pdfFile
-	out, err := cmd.Output()
+	return nil
+}
+
+// fieldsLoader returns the form fields of the filled PDF file.
+type fieldsLoader func() ([]Field, error)
+
+// fillFile fills the form PDF file located at the path.
+// The fields are only loaded if required by the options.
+func (f *Filler) fillFile(form Form, formPDFFile string, opts Options, fields fieldsLoader) (result io.Reader, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = f.fillFileTo(buf, form, formPDFFile, opts, fields)
 	if err != nil {
-		return nil, fmt.Errorf("pdftk error: %v\nOutput: %s", err, string(out))
+		return nil, err
 	}
 
-	return bytes.NewReader(out), nil
+	return bytes.NewReader(buf.Bytes()), nil
 }
 
-// Fill fills a PDF form with the specified form values and creates a final filled PDF file.
-func Fill(form Form, formPDFFile string) (result io.Reader, err error) {
-	// Get the absolute paths.
-	formPDFFile, err = filepath.Abs(formPDFFile)
+// fillFileTo fills the form PDF file located at the path and writes the
+// filled PDF file to w.
+func (f *Filler) fillFileTo(w io.Writer, form Form, formPDFFile string, opts Options, fields fieldsLoader) error {
+	return f.fillTo(w, form, formPDFFile, nil, opts, fields)
+}
+
+// fillTo fills the form PDF file located at the path, or read from the
+// reader if the reader is not nil, and writes the filled PDF file to w.
+// The output of pdftk is streamed to w, unless the options require
+// post-processing of the whole file.
+// pdftk reads either the PDF file or the FDF data from stdin, because it
+// seeks in its input files. Regular files are passed by path and the FDF
+// data is streamed through stdin. Otherwise the PDF file is streamed
+// and the FDF data is written to a temporary file.
+func (f *Filler) fillTo(w io.Writer, form Form, formPDFFile string, pdfFile io.Reader, opts Options, fields fieldsLoader) (err error) {
+	start, origForm, origOpts := time.Now(), form, opts
+	defer func() {
+		emitFillEvent(origOpts, f.auditKey, formPDFFile, origForm, start, err)
+	}()
+
+	release, err := f.acquireQuota(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create the absolute path: %v", err)
+		return err
 	}
+	cw := &countingWriter{w: w}
+	w = cw
+	defer func() {
+		release(cw.n)
+	}()
 
-	// Check if the form file exists.
-	e, err := exists(formPDFFile)
+	// Pass regular files by path instead of streaming them through stdin.
+	input, isFile := formPDFFile, pdfFile == nil
+	if !isFile {
+		input, isFile = filePath(pdfFile)
+		if !isFile {
+			input = "-"
+		}
+	}
+
+	if opts.Signed != SignedIgnore || opts.TemplateSHA256 != "" {
+		var data []byte
+		if isFile {
+			data, err = os.ReadFile(input)
+		} else {
+			data, err = io.ReadAll(pdfFile)
+			pdfFile = bytes.NewReader(data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the form PDF file: %v", err)
+		}
+
+		if opts.TemplateSHA256 != "" {
+			err = verifyChecksum(data, opts.TemplateSHA256)
+			if err != nil {
+				return err
+			}
+			opts.TemplateSHA256 = ""
+		}
+
+		unchanged, err := f.checkSigned(data, formPDFFile, opts)
+		if err != nil {
+			return err
+		} else if unchanged {
+			_, err = w.Write(data)
+			return err
+		}
+		opts.Signed = SignedIgnore
+
+		// Fill the checked data, because the file may be replaced
+		// before pdftk reads it.
+		input, isFile, pdfFile = "-", false, bytes.NewReader(data)
+	}
+
+	var cacheID string
+	if opts.Cache != nil {
+		if isFile {
+			cacheID, err = fileCacheID(input)
+		} else {
+			// The PDF file is read twice.
+			var newReader func() io.Reader
+			newReader, err = rereadable(pdfFile)
+			if err == nil {
+				pdfFile = newReader()
+				cacheID, err = dataCacheID(newReader())
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if fields != nil || !opts.needsFields() {
+		// No fields have to be loaded.
+	} else if isFile {
+		fields = func() ([]Field, error) {
+			return f.readFields(input)
+		}
+	} else {
+		// The PDF file is read twice.
+		newReader, err := rereadable(pdfFile)
+		if err != nil {
+			return err
+		}
+		pdfFile = newReader()
+		fields = func() ([]Field, error) {
+			return f.ReadFieldsFromReader(newReader())
+		}
+	}
+
+	form, opts, err = prepareForm(form, opts, fields)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check if form PDF file exists: %v", err)
-	} else if !e {
-		return nil, fmt.Errorf("form PDF file does not exist: '%s'", formPDFFile)
+		return err
+	}
+
+	// Generated document IDs differ per fill.
+	if opts.Cache != nil && (!opts.EmbedDocumentID || origOpts.DocumentID != "") {
+		if key, ok := cacheKey(cacheID, form, opts); ok {
+			return cachedFill(w, opts.Cache, key, func(w io.Writer) error {
+				return f.execute(w, input, isFile, pdfFile, form, opts)
+			})
+		}
 	}
 
-	// Check if the pdftk utility exists.
-	_, err = exec.LookPath("pdftk")
+	return f.execute(w, input, isFile, pdfFile, form, opts)
+}
+
+// execute fills the prepared form into the form PDF file, which is read
+// from the path of the input if it is a file and from pdfFile otherwise,
+// and applies the post-processing of the options.
+func (f *Filler) execute(w io.Writer, input string, isFile bool, pdfFile io.Reader, form Form, opts Options) error {
+	// Close waits for the post-processing of the fill.
+	err := f.beginFill()
 	if err != nil {
-		return nil, fmt.Errorf("pdftk utility is not installed!")
+		return err
 	}
+	defer f.endFill()
 
-	fdfFile := createFdfFile(form)
+	fdfFile := createFdfFile(form, opts)
+	defer releaseFdfFile(fdfFile)
+
+	var (
+		stdin   io.Reader
+		fdfPath = "-"
+		files   []*os.File
+	)
+	if isFile {
+		stdin = bytes.NewReader(*fdfFile)
+	} else {
+		// The form PDF file is read from stdin.
+		var cleanup func()
+		fdfPath, files, cleanup, err = f.fdfInput(*fdfFile)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		stdin = pdfFile
+	}
 
 	// Create the pdftk command line arguments.
 	args := []string{
-		formPDFFile,
-		"fill_form", "-",
+		safePath(input),
+		"fill_form", fdfPath,
 		"output", "-",
 	}
-	cmd := exec.Command("pdftk", args...)
-	cmd.Stdin = bytes.NewReader(fdfFile)
-	out, err := cmd.Output()
+	args = append(args, opts.outputArgs()...)
+
+	if !opts.needsFinish() {
+		return f.runContext(context.Background(), opts.Priority, stdin, w, files, args...)
+	}
+
+	var out bytes.Buffer
+	err = f.runContext(context.Background(), opts.Priority, stdin, &out, files, args...)
+	if err != nil {
+		return err
+	}
+
+	r, err := f.finishOutput(out.Bytes(), opts)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// fdfInput provides the FDF file to pdftk if stdin is taken by the form
+// PDF file. It returns the path to pass to pdftk and the files to pass
+// to the process. The FDF file is passed as a memory file on fd 3 if the
+// system supports it and no command prefix is set, which may not pass the
+// descriptor on. Otherwise it is written to a temporary file.
+// Call cleanup after pdftk finished.
+func (f *Filler) fdfInput(fdf []byte) (path string, files []*os.File, cleanup func(), err error) {
+	if !f.hasCommandPrefix() {
+		mem, err := memFile("fdf", fdf)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if mem != nil {
+			return "/dev/fd/3", []*os.File{mem}, func() { mem.Close() }, nil
+		}
+	}
+
+	tmp, err := os.CreateTemp(f.tempDir, "fdf")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	_, err = tmp.Write(fdf)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
-		return nil, fmt.Errorf("pdftk error: %v", err)
+		os.Remove(tmp.Name())
+		return "", nil, nil, err
+	}
+	return tmp.Name(), nil, func() { os.Remove(tmp.Name()) }, nil
+}
+
+// finishOutput applies the options to the filled PDF file.
+func (f *Filler) finishOutput(out []byte, opts Options) (result io.Reader, err error) {
+	if len(opts.Appendix) > 0 {
+		out, err = f.appendPages(opts.Priority, out, renderTextPDF(opts.Appendix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to append the appendix: %w", err)
+		}
+	}
+
+	if opts.stampText != "" {
+		out, err = f.stampPages(opts.Priority, out, opts.stampText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stamp the pages: %w", err)
+		}
+	}
+
+	if len(opts.Attachments) > 0 {
+		out, err = f.attachFiles(opts.Priority, out, opts.Attachments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach the files: %w", err)
+		}
+	}
+
+	if opts.EmbedDocumentID {
+		var hash string
+		if opts.ContentKey != nil {
+			// The hash covers the field values of the filled PDF file,
+			// as they are read when the document is verified.
+			fields, err := f.ReadFieldsFromReader(bytes.NewReader(out))
+			if err != nil {
+				return nil, fmt.Errorf("failed to embed the document ID: %w", err)
+			}
+			hash = fieldsHash(opts.ContentKey, fields)
+		}
+		out, err = f.updateInfo(opts.Priority, out, documentInfo(opts.DocumentID, hash))
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed the document ID: %w", err)
+		}
+	}
+
+	if opts.ViewerPreferences != nil || opts.InitialView != nil || opts.Lang != "" {
+		out, err = updateCatalog(out, opts.catalogEntries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update the document catalog: %w", err)
+		}
+	}
+
+	// The encryption must be the last step.
+	if opts.UserPassword != "" {
+		out, err = f.encrypt(opts.Priority, out, opts.UserPassword, opts.Permissions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt the PDF file: %w", err)
+		}
 	}
 
 	return bytes.NewReader(out), nil
 }
 
-func createFdfFile(form Form) []byte {
-	w := bytes.NewBuffer(nil)
+// fdfPool pools the buffers of the FDF data.
+var fdfPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// maxPooledFdfSize is the capacity limit of buffers returned to the pool.
+const maxPooledFdfSize = 1024 * 1024
+
+// createFdfFile writes the FDF data of the form into a pooled buffer.
+// Return the buffer with releaseFdfFile if it is not used anymore.
+func createFdfFile(form Form, opts Options) *[]byte {
+	bp := fdfPool.Get().(*[]byte)
+	b := (*bp)[:0]
 
 	// Write the fdf header.
-	fmt.Fprintln(w, fdfHeader)
-
-	// Write the form data.
-	for key, value := range form {
-		var valStr string
-		switch v := value.(type) {
-		case bool:
-			if v {
-				valStr = "Yes"
-			} else {
-				valStr = "Off"
-			}
-		case float64:
-			valStr = strconv.FormatFloat(v, 'f', -1, 64)
-		default:
-			valStr = fmt.Sprintf("%v", value)
+	b = append(b, fdfHeader...)
+	b = append(b, '\n')
+
+	// Write the form data sorted by the field names,
+	// so the same form results in the same data.
+	for _, key := range form.Keys() {
+		value := form[key]
+		flags := opts.Flags[key]
+		if opts.ReadOnly {
+			flags.Set |= FieldFlagReadOnly
+		}
+
+		b = append(b, "<< /T ("...)
+		b = appendFdfString(b, key)
+		b = append(b, ") /V ("...)
+		b = appendUTF16(b, formatValue(value), true)
+		b = append(b, ')')
+		b = flags.appendFdf(b)
+		b = append(b, ">>\n"...)
+	}
+
+	// Write the flags of the fields without values.
+	flagged := make([]string, 0, len(opts.Flags))
+	for key := range opts.Flags {
+		if _, ok := form[key]; !ok {
+			flagged = append(flagged, key)
 		}
-		fmt.Fprintf(w, "<< /T (%s) /V (%s)>>\n", key, encodeUTF16(valStr, true))
+	}
+	sort.Strings(flagged)
+	for _, key := range flagged {
+		flags := opts.Flags[key]
+
+		b = append(b, "<< /T ("...)
+		b = appendFdfString(b, key)
+		b = append(b, ')')
+		b = flags.appendFdf(b)
+		b = append(b, ">>\n"...)
 	}
 
 	// Write the fdf footer.
-	fmt.Fprintln(w, fdfFooter)
+	b = append(b, fdfFooter...)
+	b = append(b, '\n')
 
-	return w.Bytes()
+	*bp = b
+	return bp
+}
+
+// releaseFdfFile returns the buffer of the FDF data to the pool.
+func releaseFdfFile(bp *[]byte) {
+	if cap(*bp) <= maxPooledFdfSize {
+		fdfPool.Put(bp)
+	}
+}
+
+// prepareForm applies the options to the form values before they are written.
+// The returned options contain the generated appendix sections.
+// The passed form and options are not modified.
+func prepareForm(form Form, opts Options, fields fieldsLoader) (Form, Options, error) {
+	if opts.EmbedDocumentID {
+		if opts.DocumentID == "" {
+			id, err := newDocumentID()
+			if err != nil {
+				return nil, opts, err
+			}
+			opts.DocumentID = id
+		}
+	}
+
+	if opts.Mapping != nil {
+		var err error
+		form, err = opts.Mapping.Apply(form)
+		if err != nil {
+			return nil, opts, err
+		}
+	}
+
+	form, err := encodeValues(form)
+	if err != nil {
+		return nil, opts, err
+	}
+
+	form, err = renderTemplates(form, opts)
+	if err != nil {
+		return nil, opts, err
+	}
+
+	if len(opts.Rules) > 0 {
+		form, err = opts.Rules.Apply(form)
+		if err != nil {
+			return nil, opts, err
+		}
+	}
+
+	if len(opts.Expressions) > 0 {
+		form, err = opts.Expressions.Apply(form)
+		if err != nil {
+			return nil, opts, err
+		}
+	}
+
+	form, err = applyValuePolicy(form, opts)
+	if err != nil {
+		return nil, opts, err
+	}
+
+	if len(opts.Masks) > 0 {
+		var full []byte
+		form, full, err = maskValues(form, opts)
+		if err != nil {
+			return nil, opts, err
+		} else if full != nil {
+			opts.Attachments = append(opts.Attachments[:len(opts.Attachments):len(opts.Attachments)], Attachment{
+				Name: MaskedValuesAttachment,
+				Data: full,
+			})
+		}
+	}
+
+	if opts.Stamp != nil {
+		opts.stampText, err = opts.Stamp.render(form, opts)
+		if err != nil {
+			return nil, opts, err
+		}
+	}
+
+	if opts.Provenance != nil {
+		a, err := opts.Provenance.attachment(form)
+		if err != nil {
+			return nil, opts, err
+		}
+		opts.Attachments = append(opts.Attachments[:len(opts.Attachments):len(opts.Attachments)], a)
+	}
+
+	if !opts.needsFields() {
+		return form, opts, nil
+	} else if fields == nil {
+		return nil, opts, fmt.Errorf("form fields are not available")
+	}
+	fs, err := fields()
+	if err != nil {
+		return nil, opts, err
+	}
+
+	if opts.Validate {
+		err = validateForm(form, fs)
+		if err != nil {
+			return nil, opts, err
+		}
+	}
+
+	if opts.SpillLongValues {
+		var spill *Section
+		form, spill = spillLongValues(form, fs, opts)
+		if spill != nil {
+			opts.Appendix = append(opts.Appendix[:len(opts.Appendix):len(opts.Appendix)], *spill)
+		}
+	}
+
+	return form, opts, nil
+}
+
+// formatValue returns the string representation of a form value.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "Off"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+
+	if e, ok := lookupEncoder(value); ok {
+		if s, err := e(value); err == nil {
+			return s
+		}
+	}
+
+	// Decimal types are formatted exactly by their String method.
+	return fmt.Sprintf("%v", value)
 }
 
 // exists returns whether the given file or directory exists or not
@@ -148,22 +645,55 @@ func exists(path string) (bool, error) {
 	return false, err
 }
 
-// Taken from https://gist.github.com/ik5/65de721ca495fa1bf451
-// EncodeUTF16 get a utf8 string and translate it into a slice of bytes of ucs2
-func encodeUTF16(s string, addBom bool) []byte {
-	r := []rune(s)
-	iresult := utf16.Encode(r)
-	var bytes []byte
+// Based on https://gist.github.com/ik5/65de721ca495fa1bf451
+// appendUTF16 appends the utf8 string as big endian UTF-16 bytes to the slice.
+// Characters outside of the Basic Multilingual Plane, like emoji, are
+// written as surrogate pairs.
+// The bytes are escaped as content of a PDF literal string, because the
+// code units of characters like ')' contain the string delimiters.
+func appendUTF16(b []byte, s string, addBom bool) []byte {
 	if addBom {
-		bytes = make([]byte, 2)
-		bytes = []byte{254, 255}
+		b = append(b, 254, 255)
+	}
+	for _, r := range s {
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+			b = appendFdfCodeUnit(b, uint16(r1))
+			b = appendFdfCodeUnit(b, uint16(r2))
+		} else {
+			b = appendFdfCodeUnit(b, uint16(r))
+		}
+	}
+	return b
+}
+
+// appendFdfCodeUnit appends the escaped big endian bytes of the UTF-16 code unit.
+func appendFdfCodeUnit(b []byte, u uint16) []byte {
+	b = appendFdfByte(b, byte(u>>8))
+	return appendFdfByte(b, byte(u))
+}
+
+// appendFdfString appends the string escaped as content of a PDF literal string,
+// so it can't terminate the string and inject FDF objects.
+func appendFdfString(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		b = appendFdfByte(b, s[i])
 	}
-	for _, i := range iresult {
-		temp := make([]byte, 2)
-		binary.BigEndian.PutUint16(temp, i)
-		bytes = append(bytes, temp...)
+	return b
+}
+
+// appendFdfByte appends the byte escaped as content of a PDF literal string.
+// Delimiters and the escape character are prefixed with a backslash.
+// Line break bytes, which PDF readers normalize, are written as octal
+// escape sequences.
+func appendFdfByte(b []byte, c byte) []byte {
+	switch c {
+	case '(', ')', '\\':
+		return append(b, '\\', c)
+	case '\r', '\n':
+		return append(b, '\\', '0', '0'+c>>3, '0'+c&7)
+	default:
+		return append(b, c)
 	}
-	return bytes
 }
 
 const fdfHeader = `%FDF-1.2
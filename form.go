@@ -0,0 +1,184 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxRequestMemory is the memory used to parse multipart requests.
+// Larger files are stored on disk.
+const maxRequestMemory = 32 << 20
+
+// FormFromMap creates a form from the text values of the map.
+func FormFromMap(m map[string]string) Form {
+	form := make(Form, len(m))
+	for k, v := range m {
+		form[k] = v
+	}
+	return form
+}
+
+// FormFromValues creates a form from the first value of every key
+// of the URL values.
+func FormFromValues(values url.Values) Form {
+	form := make(Form, len(values))
+	for k := range values {
+		form[k] = values.Get(k)
+	}
+	return form
+}
+
+// FormFromRequest creates a form from the URL-encoded or multipart form
+// values of the HTTP request. Browsers don't post unchecked check boxes,
+// therefore check boxes of the fields without a value are switched off
+// and posted ones are switched on. The fields may be nil.
+func FormFromRequest(r *http.Request, fields []Field) (Form, error) {
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		err = r.ParseMultipartForm(maxRequestMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	form := FormFromValues(r.Form)
+	for _, f := range fields {
+		if !f.IsCheckbox() {
+			continue
+		}
+		if _, ok := form[f.Name]; ok {
+			form[f.Name] = checkboxValue(f)
+		} else {
+			form[f.Name] = "Off"
+		}
+	}
+	return form, nil
+}
+
+// FormFromRequest creates a form from the form values of the HTTP request
+// with the check box semantics of the template fields.
+// See the package-level FormFromRequest.
+func (t *Template) FormFromRequest(r *http.Request) (Form, error) {
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+	return FormFromRequest(r, fields)
+}
+
+// Merge returns a new form with the values of the form and the other forms.
+// Values of later forms take precedence.
+func (f Form) Merge(others ...Form) Form {
+	n := len(f)
+	for _, o := range others {
+		n += len(o)
+	}
+
+	result := make(Form, n)
+	for k, v := range f {
+		result[k] = v
+	}
+	for _, o := range others {
+		for k, v := range o {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// Clone returns a deep copy of the form. Nested maps, slices and
+// forms are copied, other values are shared.
+func (f Form) Clone() Form {
+	if f == nil {
+		return nil
+	}
+
+	result := make(Form, len(f))
+	for k, v := range f {
+		result[k] = cloneValue(v)
+	}
+	return result
+}
+
+// cloneValue returns a deep copy of the nested maps and slices of the value.
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Form:
+		return v.Clone()
+	case map[string]interface{}:
+		return map[string]interface{}(Form(v).Clone())
+	case map[string]string:
+		m := make(map[string]string, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = cloneValue(e)
+		}
+		return s
+	case []string:
+		return append([]string(nil), v...)
+	case []byte:
+		return append([]byte(nil), v...)
+	default:
+		return value
+	}
+}
+
+// Keys returns the sorted field names of the form.
+func (f Form) Keys() []string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Diff compares the form with the fields of a template. It returns the
+// sorted names of the form values without a field and of the fields
+// without a form value.
+func (f Form) Diff(fields []Field) (unknown, missing []string) {
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		names[field.Name] = true
+		if _, ok := f[field.Name]; !ok {
+			missing = append(missing, field.Name)
+		}
+	}
+
+	for k := range f {
+		if !names[k] {
+			unknown = append(unknown, k)
+		}
+	}
+
+	sort.Strings(unknown)
+	sort.Strings(missing)
+	return unknown, missing
+}
@@ -0,0 +1,379 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var objRegexp = regexp.MustCompile(`(?:^|[^0-9])(\d+)\s+(\d+)\s+obj\b`)
+
+// Rect is a rectangle in PDF user space units of 1/72 inch.
+// The origin is the lower left corner of the page.
+type Rect struct {
+	X1, Y1 float64
+	X2, Y2 float64
+}
+
+// Width returns the width of the rectangle.
+func (r Rect) Width() float64 {
+	return r.X2 - r.X1
+}
+
+// Height returns the height of the rectangle.
+func (r Rect) Height() float64 {
+	return r.Y2 - r.Y1
+}
+
+// Contains returns whether the rectangle contains the other rectangle.
+func (r Rect) Contains(o Rect) bool {
+	return o.X1 >= r.X1 && o.Y1 >= r.Y1 && o.X2 <= r.X2 && o.Y2 <= r.Y2
+}
+
+// Widget is the visible area of a form field on a page.
+// Fields shown on several pages have multiple widgets.
+type Widget struct {
+	// Field is the fully qualified name of the field.
+	Field string
+
+	// Page is the one-based page number.
+	Page int
+
+	// Rect is the area of the widget on the page.
+	Rect Rect
+
+	// MediaBox is the area of the page.
+	MediaBox Rect
+}
+
+// ReadWidgets returns the widgets of the form fields of the PDF file in
+// the order of the pages, so previews can highlight where the values are
+// printed and stamps can be placed relative to fields.
+func ReadWidgets(pdfFile io.Reader) ([]Widget, error) {
+	return DefaultFiller.ReadWidgets(pdfFile)
+}
+
+// ReadWidgets returns the widgets of the form fields of the PDF file.
+// See the package-level ReadWidgets.
+func (f *Filler) ReadWidgets(pdfFile io.Reader) ([]Widget, error) {
+	data, err := io.ReadAll(pdfFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the PDF file: %v", err)
+	}
+	widgets, _, err := f.readWidgets(data)
+	return widgets, err
+}
+
+// Widgets returns the widgets of the form fields of the template.
+// See the package-level ReadWidgets.
+func (t *Template) Widgets() ([]Widget, error) {
+	data, err := t.readData()
+	if err != nil {
+		return nil, err
+	}
+	widgets, _, err := t.filler.readWidgets(data)
+	return widgets, err
+}
+
+// readWidgets returns the widgets of the form fields and the media
+// boxes of the pages of the PDF file. Files with object streams are
+// rewritten by pdftk first, which writes the objects uncompressed.
+func (f *Filler) readWidgets(data []byte) ([]Widget, []Rect, error) {
+	if bytes.Contains(data, []byte("/ObjStm")) || !bytes.Contains(data, []byte("trailer")) {
+		var err error
+		data, err = f.output(PriorityNormal, bytes.NewReader(data), "-", "output", "-")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return parseWidgets(data)
+}
+
+// pdfObjects contains the dictionaries of the objects of a PDF file
+// by their "<num> <gen>" reference.
+type pdfObjects map[string]string
+
+// parseObjects returns the dictionaries of the objects of the PDF file.
+// Later definitions of incremental updates replace earlier ones.
+// Objects in object streams are not found, therefore the file must be
+// rewritten by pdftk first.
+func parseObjects(pdfFile []byte) pdfObjects {
+	objs := make(pdfObjects)
+	for _, m := range objRegexp.FindAllSubmatchIndex(pdfFile, -1) {
+		body := pdfFile[m[1]:]
+		if end := bytes.Index(body, []byte("endobj")); end >= 0 {
+			body = body[:end]
+		}
+
+		s := strings.TrimSpace(string(body))
+		if !strings.HasPrefix(s, "<<") {
+			continue
+		}
+		n, err := pdfValueLen(s)
+		if err != nil {
+			continue
+		}
+		objs[string(pdfFile[m[2]:m[3]])+" "+string(pdfFile[m[4]:m[5]])] = s[:n]
+	}
+	return objs
+}
+
+// dict returns the entries of the dictionary, which is resolved
+// if the value is an indirect reference.
+func (o pdfObjects) dict(value string) [][2]string {
+	value = strings.TrimSpace(value)
+	if refRegexp.MatchString(value) {
+		fields := strings.Fields(value)
+		value = o[fields[0]+" "+fields[1]]
+	}
+	dict, _ := parsePDFDict(value)
+	return dict
+}
+
+// value returns the value of the key, which is resolved if it is an
+// indirect reference to a dictionary.
+func (o pdfObjects) value(dict [][2]string, key string) string {
+	v := pdfDictValue(dict, key)
+	if refRegexp.MatchString(v) {
+		fields := strings.Fields(v)
+		if obj, ok := o[fields[0]+" "+fields[1]]; ok {
+			return obj
+		}
+	}
+	return v
+}
+
+// parseWidgets returns the widgets of the form fields of the PDF file
+// in the order of the pages and the media boxes of the pages.
+func parseWidgets(pdfFile []byte) ([]Widget, []Rect, error) {
+	i := bytes.LastIndex(pdfFile, []byte("trailer"))
+	if i < 0 {
+		return nil, nil, fmt.Errorf("unsupported PDF file: cross-reference streams")
+	}
+	m := rootRegexp.FindSubmatch(pdfFile[i:])
+	if m == nil {
+		return nil, nil, fmt.Errorf("invalid PDF file: missing catalog")
+	}
+
+	objs := parseObjects(pdfFile)
+	catalog := objs.dict(string(m[1]) + " " + string(m[2]) + " R")
+	if catalog == nil {
+		return nil, nil, fmt.Errorf("invalid PDF file: missing catalog")
+	}
+
+	var (
+		widgets []Widget
+		pages   []Rect
+
+		// visited contains the objects of the walked page tree nodes,
+		// so nodes listed several times don't multiply the walk.
+		visited = make(map[string]bool)
+	)
+
+	var walk func(ref string, mediaBox Rect, depth int) error
+	walk = func(ref string, mediaBox Rect, depth int) error {
+		if depth > 32 {
+			return fmt.Errorf("invalid PDF file: page tree too deep")
+		}
+		if ref = strings.TrimSpace(ref); refRegexp.MatchString(ref) {
+			f := strings.Fields(ref)
+			id := f[0] + " " + f[1]
+			if visited[id] {
+				return fmt.Errorf("invalid PDF file: page tree node %s R is referenced twice", id)
+			}
+			visited[id] = true
+		}
+		node := objs.dict(ref)
+
+		// The media box is inherited by the kids.
+		if box, ok := parseRect(objs.value(node, "/MediaBox")); ok {
+			mediaBox = box
+		}
+
+		if pdfDictValue(node, "/Type") != "/Page" {
+			for _, kid := range kidsRegexp.FindAllString(objs.value(node, "/Kids"), -1) {
+				err := walk(kid, mediaBox, depth+1)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		pages = append(pages, mediaBox)
+		page := len(pages)
+		for _, ref := range kidsRegexp.FindAllString(objs.value(node, "/Annots"), -1) {
+			annot := objs.dict(ref)
+			if pdfDictValue(annot, "/Subtype") != "/Widget" {
+				continue
+			}
+
+			rect, _ := parseRect(objs.value(annot, "/Rect"))
+			widgets = append(widgets, Widget{
+				Field:    objs.fieldName(annot),
+				Page:     page,
+				Rect:     rect,
+				MediaBox: mediaBox,
+			})
+		}
+		return nil
+	}
+
+	err := walk(pdfDictValue(catalog, "/Pages"), Rect{}, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return widgets, pages, nil
+}
+
+// fieldName returns the fully qualified name of the field of the widget,
+// which joins the partial names of the field and its parents with dots.
+func (o pdfObjects) fieldName(dict [][2]string) string {
+	var parts []string
+	for depth := 0; dict != nil && depth < 32; depth++ {
+		if t := pdfDictValue(dict, "/T"); t != "" {
+			parts = append([]string{decodePDFString(t)}, parts...)
+		}
+		parent := pdfDictValue(dict, "/Parent")
+		if parent == "" {
+			break
+		}
+		dict = o.dict(parent)
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseRect parses a rectangle array and normalizes it to the lower left
+// and upper right corners.
+func parseRect(s string) (Rect, bool) {
+	var r [4]float64
+
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return Rect{}, false
+	}
+	fields := strings.Fields(s[1 : len(s)-1])
+	if len(fields) != 4 {
+		return Rect{}, false
+	}
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return Rect{}, false
+		}
+		r[i] = v
+	}
+
+	return Rect{
+		X1: min(r[0], r[2]), Y1: min(r[1], r[3]),
+		X2: max(r[0], r[2]), Y2: max(r[1], r[3]),
+	}, true
+}
+
+// decodePDFString decodes a literal or hexadecimal PDF string.
+// UTF-16 strings are detected by their byte order mark, other strings
+// are decoded as Latin-1, which matches PDFDocEncoding for most text.
+func decodePDFString(s string) string {
+	var b []byte
+
+	switch {
+	case strings.HasPrefix(s, "<"):
+		h := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(" \t\r\n\f", r) {
+				return -1
+			}
+			return r
+		}, strings.Trim(s, "<>"))
+		if len(h)%2 == 1 {
+			h += "0"
+		}
+		b, _ = hex.DecodeString(h)
+	case strings.HasPrefix(s, "("):
+		b = unescapePDFString(strings.TrimSuffix(s[1:], ")"))
+	default:
+		return s
+	}
+
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// unescapePDFString resolves the escape sequences of a literal string.
+func unescapePDFString(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b = append(b, c)
+			continue
+		}
+
+		i++
+		switch c = s[i]; c {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case '\r':
+			// Line continuation.
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+			// Line continuation.
+		default:
+			if c < '0' || c > '7' {
+				b = append(b, c)
+				continue
+			}
+			v := 0
+			for n := 0; n < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; n++ {
+				v = v*8 + int(s[i]-'0')
+				i++
+			}
+			i--
+			b = append(b, byte(v))
+		}
+	}
+	return b
+}
@@ -0,0 +1,101 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// HTMLForm returns a basic HTML form with an input element for every
+// form field of the template. The form is posted to the action URL.
+// Check boxes post the value "Yes" if checked.
+func (t *Template) HTMLForm(action string) ([]byte, error) {
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []Field
+	for _, f := range fields {
+		if f.IsPushbutton() || f.Type == FieldTypeSignature {
+			continue
+		}
+		inputs = append(inputs, f)
+	}
+
+	w := bytes.NewBuffer(nil)
+	err = htmlFormTemplate.Execute(w, struct {
+		Action string
+		Fields []Field
+	}{
+		Action: action,
+		Fields: inputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Bytes(), nil
+}
+
+var htmlFormTemplate = template.Must(template.New("form").Funcs(template.FuncMap{
+	"multiline": func(f Field) bool {
+		return f.Flags&FieldFlagMultiline != 0
+	},
+	"checkboxValue": checkboxValue,
+}).Parse(
+	`<form method="post" action="{{.Action}}">
+{{- range .Fields}}
+  <label>{{if .AltName}}{{.AltName}}{{else}}{{.Name}}{{end}}
+{{- if .IsCheckbox}}
+    <input type="checkbox" name="{{.Name}}" value="{{checkboxValue .}}"{{if eq .Value (checkboxValue .)}} checked{{end}}{{template "attrs" .}}>
+{{- else if .IsRadio}}
+{{- $f := .}}
+{{- range .Options}}{{if ne . "Off"}}
+    <input type="radio" name="{{$f.Name}}" value="{{.}}"{{if eq $f.Value .}} checked{{end}}{{template "attrs" $f}}> {{.}}
+{{- end}}{{end}}
+{{- else if eq .Type "Choice"}}
+    <select name="{{.Name}}"{{template "attrs" .}}>
+{{- $f := .}}
+{{- range .Options}}
+      <option{{if eq $f.Value .}} selected{{end}}>{{.}}</option>
+{{- end}}
+    </select>
+{{- else if multiline .}}
+    <textarea name="{{.Name}}"{{if .MaxLength}} maxlength="{{.MaxLength}}"{{end}}{{template "attrs" .}}>{{.Value}}</textarea>
+{{- else}}
+    <input type="text" name="{{.Name}}" value="{{.Value}}"{{if .MaxLength}} maxlength="{{.MaxLength}}"{{end}}{{template "attrs" .}}>
+{{- end}}
+  </label>
+{{- end}}
+  <button type="submit">Submit</button>
+</form>
+{{define "attrs"}}{{if .IsRequired}} required{{end}}{{if .IsReadOnly}} readonly{{end}}{{end}}`,
+))
+
+// checkboxValue returns the value of the checked state of a check box.
+func checkboxValue(f Field) string {
+	for _, o := range f.Options {
+		if o != "Off" {
+			return o
+		}
+	}
+	return "Yes"
+}
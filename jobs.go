@@ -0,0 +1,406 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobID identifies an asynchronous fill job.
+type JobID string
+
+// JobState is the state of a fill job.
+type JobState string
+
+// States of fill jobs.
+const (
+	JobPending JobState = "pending"
+	JobRunning JobState = "running"
+	JobDone    JobState = "done"
+	JobFailed  JobState = "failed"
+)
+
+// Job is an asynchronous fill of a form PDF file.
+// Jobs are filled with the default options of the Filler or with their
+// request options, which are stored with the job, so they can be resumed
+// after a restart.
+type Job struct {
+	ID       JobID           `json:"id"`
+	State    JobState        `json:"state"`
+	Form     Form            `json:"form"`
+	Template string          `json:"template"`
+	Options  *RequestOptions `json:"options,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Created  time.Time       `json:"created"`
+	Updated  time.Time       `json:"updated"`
+}
+
+const (
+	// jobSaveAttempts is the number of attempts to save the state of a job.
+	jobSaveAttempts = 3
+
+	// jobSaveRetryDelay is the delay before the first retry, which
+	// increases with every attempt.
+	jobSaveRetryDelay = 100 * time.Millisecond
+)
+
+// ErrJobNotFound is returned for unknown job IDs.
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStore persists fill jobs and their results.
+// Implementations must be safe for concurrent use.
+type JobStore interface {
+	SaveJob(job Job) error
+	LoadJob(id JobID) (Job, error)
+	SaveResult(id JobID, data []byte) error
+	LoadResult(id JobID) ([]byte, error)
+
+	// UnfinishedJobs returns the pending and running jobs.
+	UnfinishedJobs() ([]Job, error)
+}
+
+// SubmitFill starts filling the form PDF file with the default options in
+// the background and returns the ID of the job. Use JobStatus and
+// JobResult to poll the job.
+func (f *Filler) SubmitFill(form Form, formPDFFile string) (JobID, error) {
+	return f.submitFill(form, formPDFFile, nil)
+}
+
+// SubmitFillWithOptions starts filling the form PDF file with the request
+// options in the background, like a large batch with a low Priority.
+// The options are stored with the job, including the UserPassword in
+// plain text. See SubmitFill.
+func (f *Filler) SubmitFillWithOptions(form Form, formPDFFile string, opts RequestOptions) (JobID, error) {
+	return f.submitFill(form, formPDFFile, &opts)
+}
+
+// submitFill saves and starts a new job.
+func (f *Filler) submitFill(form Form, formPDFFile string, opts *RequestOptions) (JobID, error) {
+	formPDFFile, err := resolveFormFile(formPDFFile)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	job := Job{
+		ID:       JobID(hex.EncodeToString(b)),
+		State:    JobPending,
+		Form:     form,
+		Template: formPDFFile,
+		Options:  opts,
+		Created:  now,
+		Updated:  now,
+	}
+	err = f.jobs.SaveJob(job)
+	if err != nil {
+		return "", fmt.Errorf("failed to save the job: %v", err)
+	}
+
+	go f.runJob(job)
+	return job.ID, nil
+}
+
+// JobStatus returns the job with the ID.
+func (f *Filler) JobStatus(id JobID) (Job, error) {
+	return f.jobs.LoadJob(id)
+}
+
+// JobResult returns the filled PDF file of the finished job.
+func (f *Filler) JobResult(id JobID) (io.Reader, error) {
+	job, err := f.jobs.LoadJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.State {
+	case JobDone:
+	case JobFailed:
+		return nil, fmt.Errorf("job failed: %s", job.Error)
+	default:
+		return nil, fmt.Errorf("job is %s", job.State)
+	}
+
+	data, err := f.jobs.LoadResult(id)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// ResumeJobs restarts the unfinished jobs of the job store,
+// like after a restart of the process.
+func (f *Filler) ResumeJobs() error {
+	jobs, err := f.jobs.UnfinishedJobs()
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		go f.runJob(job)
+	}
+	return nil
+}
+
+// runJob fills the form of the job and saves the result.
+// Jobs interrupted by Close stay pending.
+func (f *Filler) runJob(job Job) {
+	job.State = JobRunning
+	job.Updated = time.Now()
+	if f.saveJob(job) != nil {
+		return
+	}
+
+	opts := f.opts
+	if job.Options != nil {
+		opts = job.Options.options(f.opts)
+	}
+
+	result, err := f.FillWithOptions(job.Form, job.Template, opts)
+	if errors.Is(err, ErrClosed) {
+		job.State = JobPending
+		job.Updated = time.Now()
+		f.saveJob(job)
+		return
+	}
+
+	if err == nil {
+		var data []byte
+		data, err = io.ReadAll(result)
+		if err == nil {
+			err = f.jobs.SaveResult(job.ID, data)
+		}
+	}
+
+	if err != nil {
+		job.State = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.State = JobDone
+	}
+	job.Updated = time.Now()
+	f.saveJob(job)
+}
+
+// saveJob saves the state of the job. Failed writes are retried, because
+// a job left running would be filled again by ResumeJobs. The error of
+// the last attempt is logged with the logger of the filler.
+func (f *Filler) saveJob(job Job) (err error) {
+	for attempt := 0; attempt < jobSaveAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * jobSaveRetryDelay)
+		}
+		err = f.jobs.SaveJob(job)
+		if err == nil {
+			return nil
+		}
+	}
+
+	if f.logger != nil {
+		f.logger.Printf("job %s: failed to save the %s state: %v", job.ID, job.State, err)
+	}
+	return err
+}
+
+// MemoryJobStore is a JobStore keeping the jobs in memory.
+// Jobs are lost on restart.
+type MemoryJobStore struct {
+	mutex   sync.Mutex
+	jobs    map[JobID]Job
+	results map[JobID][]byte
+}
+
+// NewMemoryJobStore creates a new empty in-memory job store.
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{
+		jobs:    make(map[JobID]Job),
+		results: make(map[JobID][]byte),
+	}
+}
+
+// SaveJob stores the job.
+func (s *MemoryJobStore) SaveJob(job Job) error {
+	s.mutex.Lock()
+	s.jobs[job.ID] = job
+	s.mutex.Unlock()
+	return nil
+}
+
+// LoadJob returns the job with the ID.
+func (s *MemoryJobStore) LoadJob(id JobID) (Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// SaveResult stores the filled PDF file of the job.
+func (s *MemoryJobStore) SaveResult(id JobID, data []byte) error {
+	s.mutex.Lock()
+	s.results[id] = data
+	s.mutex.Unlock()
+	return nil
+}
+
+// LoadResult returns the filled PDF file of the job.
+func (s *MemoryJobStore) LoadResult(id JobID) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, ok := s.results[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return data, nil
+}
+
+// UnfinishedJobs returns the pending and running jobs.
+func (s *MemoryJobStore) UnfinishedJobs() ([]Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var jobs []Job
+	for _, job := range s.jobs {
+		if job.State == JobPending || job.State == JobRunning {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.Before(jobs[j].Created)
+	})
+	return jobs, nil
+}
+
+// DirJobStore is a JobStore storing every job as JSON file and
+// its result as PDF file in a directory, so jobs survive restarts.
+type DirJobStore string
+
+// SaveJob writes the JSON file of the job.
+func (d DirJobStore) SaveJob(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return d.write(job.ID, ".json", data)
+}
+
+// LoadJob reads the JSON file of the job.
+func (d DirJobStore) LoadJob(id JobID) (job Job, err error) {
+	data, err := d.read(id, ".json")
+	if err != nil {
+		return job, err
+	}
+
+	// Numbers are kept as json.Number, so large integers don't lose precision.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&job)
+	return job, err
+}
+
+// SaveResult writes the filled PDF file of the job.
+func (d DirJobStore) SaveResult(id JobID, data []byte) error {
+	return d.write(id, ".pdf", data)
+}
+
+// LoadResult reads the filled PDF file of the job.
+func (d DirJobStore) LoadResult(id JobID) ([]byte, error) {
+	return d.read(id, ".pdf")
+}
+
+// UnfinishedJobs returns the pending and running jobs.
+func (d DirJobStore) UnfinishedJobs() ([]Job, error) {
+	paths, err := filepath.Glob(filepath.Join(string(d), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []Job
+	for _, path := range paths {
+		job, err := d.LoadJob(JobID(strings.TrimSuffix(filepath.Base(path), ".json")))
+		if err != nil {
+			return nil, err
+		}
+		if job.State == JobPending || job.State == JobRunning {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.Before(jobs[j].Created)
+	})
+	return jobs, nil
+}
+
+// write replaces the file of the job atomically.
+func (d DirJobStore) write(id JobID, ext string, data []byte) error {
+	path, err := d.path(id, ext)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// read returns the content of the file of the job.
+func (d DirJobStore) read(id JobID, ext string) ([]byte, error) {
+	path, err := d.path(id, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrJobNotFound
+	}
+	return data, err
+}
+
+// path returns the file path of the job.
+func (d DirJobStore) path(id JobID, ext string) (string, error) {
+	if id == "" || !filepath.IsLocal(string(id)) || strings.ContainsAny(string(id), `/\`) {
+		return "", fmt.Errorf("invalid job ID: '%s'", id)
+	}
+	return filepath.Join(string(d), string(id)+ext), nil
+}
@@ -0,0 +1,128 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LintIssue describes a problem of a form PDF file.
+type LintIssue struct {
+	// Field is empty for problems of the whole file.
+	Field string
+	Msg   string
+}
+
+func (i LintIssue) String() string {
+	if i.Field == "" {
+		return i.Msg
+	}
+	return fmt.Sprintf("field '%s': %s", i.Field, i.Msg)
+}
+
+// Lint checks the form PDF file for common template problems, like
+// duplicate field names with different types, zero-size widgets, buttons
+// without export values, widgets outside their page and XFA data.
+// The issues are sorted by the field name.
+func Lint(formPDFFile string) ([]LintIssue, error) {
+	return DefaultFiller.Lint(formPDFFile)
+}
+
+// Lint checks the form PDF file for common template problems.
+// See the package-level Lint.
+func (f *Filler) Lint(formPDFFile string) ([]LintIssue, error) {
+	t, err := f.NewTemplate(formPDFFile)
+	if err != nil {
+		return nil, err
+	}
+	return t.Lint()
+}
+
+// Lint checks the template for common template problems.
+// See the package-level Lint.
+func (t *Template) Lint() ([]LintIssue, error) {
+	data, err := t.readData()
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	widgets, _, err := t.filler.readWidgets(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return lintTemplate(data, fields, widgets), nil
+}
+
+// lintTemplate returns the issues of the form PDF file.
+func lintTemplate(data []byte, fields []Field, widgets []Widget) []LintIssue {
+	var issues []LintIssue
+
+	if newScanner(data).hasName("XFA") {
+		issues = append(issues, LintIssue{Msg: "form contains XFA data, which is not filled by pdftk"})
+	}
+
+	types := make(map[string]FieldType, len(fields))
+	for _, field := range fields {
+		if t, ok := types[field.Name]; ok && t != field.Type {
+			issues = append(issues, LintIssue{
+				Field: field.Name,
+				Msg:   fmt.Sprintf("duplicate field name with types %s and %s", t, field.Type),
+			})
+		}
+		types[field.Name] = field.Type
+
+		if field.IsCheckbox() || field.IsRadio() {
+			hasExport := false
+			for _, o := range field.Options {
+				if o != "Off" {
+					hasExport = true
+				}
+			}
+			if !hasExport {
+				issues = append(issues, LintIssue{Field: field.Name, Msg: "button has no export value"})
+			}
+		}
+	}
+
+	for _, w := range widgets {
+		if w.Rect.Width() <= 0 || w.Rect.Height() <= 0 {
+			issues = append(issues, LintIssue{
+				Field: w.Field,
+				Msg:   fmt.Sprintf("widget on page %d has zero size", w.Page),
+			})
+		} else if w.MediaBox != (Rect{}) && !w.MediaBox.Contains(w.Rect) {
+			issues = append(issues, LintIssue{
+				Field: w.Field,
+				Msg:   fmt.Sprintf("widget on page %d is outside the page bounds", w.Page),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Field < issues[j].Field
+	})
+	return issues
+}
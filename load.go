@@ -0,0 +1,116 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FormFromJSON creates a form from a JSON object. Nested objects and
+// arrays are flattened, see FormFromNested. Numbers are kept exactly.
+func FormFromJSON(r io.Reader) (Form, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var doc map[string]interface{}
+	err := dec.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the JSON document: %v", err)
+	}
+	return FormFromNested(doc), nil
+}
+
+// FormFromYAML creates a form from a YAML mapping. Nested mappings and
+// sequences are flattened, see FormFromNested. Numbers are kept exactly.
+// Anchors, aliases, tags and multiple documents are not supported.
+func FormFromYAML(r io.Reader) (Form, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the YAML document: %v", err)
+	}
+
+	v, err := decodeYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the YAML document: %v", err)
+	}
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to decode the YAML document: expected a mapping")
+	}
+	return FormFromNested(doc), nil
+}
+
+// FormFromTOML creates a form from a TOML document. Tables and arrays are
+// flattened, see FormFromNested. Numbers are kept exactly and dates and
+// times are kept as written.
+func FormFromTOML(r io.Reader) (Form, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the TOML document: %v", err)
+	}
+
+	doc, err := decodeTOML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the TOML document: %v", err)
+	}
+	return FormFromNested(doc), nil
+}
+
+// FormFromNested creates a form from a nested document, like a decoded
+// JSON, YAML or TOML document. The keys of nested maps are joined with
+// dots to fully qualified field names, like "Client.Name", and array
+// elements get their index as key, like "Items.0.Name".
+func FormFromNested(doc map[string]interface{}) Form {
+	form := make(Form, len(doc))
+	flattenValue(form, "", doc)
+	return form
+}
+
+// flattenValue adds the value with the prefix to the form.
+func flattenValue(form Form, prefix string, value interface{}) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			flattenValue(form, join(k), e)
+		}
+	case map[interface{}]interface{}:
+		// Decoded by some YAML libraries.
+		for k, e := range v {
+			flattenValue(form, join(fmt.Sprint(k)), e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			flattenValue(form, join(strconv.Itoa(i)), e)
+		}
+	case nil:
+		form[prefix] = ""
+	default:
+		form[prefix] = v
+	}
+}
@@ -0,0 +1,388 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Types of mapped values.
+const (
+	MappingText     = "text"
+	MappingNumber   = "number"
+	MappingDate     = "date"
+	MappingCheckbox = "checkbox"
+
+	// MappingPhone formats E.164 phone numbers in the national format
+	// of the country. See FormatPhone.
+	MappingPhone = "phone"
+
+	// MappingPostalCode normalizes postal codes of the country.
+	// See NormalizePostalCode.
+	MappingPostalCode = "postal_code"
+)
+
+// Paddings of mapped values with a width.
+const (
+	// PadRight appends spaces, which aligns values left.
+	PadRight = "right"
+
+	// PadLeft prepends spaces, which aligns values right, like amounts.
+	PadLeft = "left"
+
+	// PadZero prepends zeros after the sign, like for account numbers.
+	PadZero = "zero"
+)
+
+// Mapping maps the keys of input data to form fields, so mappings can be
+// adjusted in a file without recompiling. Keys without a mapping are
+// skipped. In files, a field name can be used instead of a FieldMapping:
+//
+//	{
+//		"first_name": "First Name",
+//		"amount": {"field": "Amount", "type": "number", "format": "%.2f"},
+//		"country": {"field": "Country", "default": "US"},
+//		"date": {"field": "Date", "expression": "today()"},
+//		"name": {"field": "Name", "expression": "concat(first, \" \", last)"},
+//		"state": {"field": "State", "lookup": {"CA": "California"}},
+//		"account": {"field": "Account", "width": 10, "pad": "zero"},
+//		"phone": {"field": "Phone", "type": "phone", "country": "US"},
+//		"weight": {"field": "Weight", "convert": ["kg_to_lbs"], "type": "number", "format": "%.1f"}
+//	}
+type Mapping map[string]FieldMapping
+
+// FieldMapping describes the form field of a data key.
+type FieldMapping struct {
+	// Field is the field name. Defaults to the key.
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+
+	// Type converts the value to MappingText (default), MappingNumber,
+	// MappingDate, MappingCheckbox, MappingPhone or MappingPostalCode.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the country of
+	// MappingPhone and MappingPostalCode values, like "US".
+	Country string `json:"country,omitempty" yaml:"country,omitempty"`
+
+	// Format is the fmt verb of numbers, like "%.2f", or the time layout
+	// of dates, like "01/02/2006". Dates are filled as "2006-01-02" by
+	// default.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Default is used for missing and empty values.
+	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Expression computes missing and empty values from the other values
+	// of the data, like "today()" or "concat(first, \" \", last)".
+	// It is evaluated before the default is used. See Expressions for the
+	// syntax. The keys of the data are the field names of the expression.
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"`
+
+	// Lookup replaces the value with the entry of the table, like a state
+	// code with the state name. Values without an entry are replaced by
+	// the default, if set, and fail otherwise. Empty values are kept.
+	Lookup map[string]string `json:"lookup,omitempty" yaml:"lookup,omitempty"`
+
+	// Convert are the names of registered converters, which are applied
+	// in order before the value is converted to the type.
+	// See RegisterConverter.
+	Convert []string `json:"convert,omitempty" yaml:"convert,omitempty"`
+
+	// Width pads the converted value to a fixed number of characters for
+	// fields with a box per character, like on banking forms. Longer
+	// values fail. Empty values are kept.
+	Width int `json:"width,omitempty" yaml:"width,omitempty"`
+
+	// Pad is the padding of values with a width: PadRight (default),
+	// PadLeft or PadZero.
+	Pad string `json:"pad,omitempty" yaml:"pad,omitempty"`
+}
+
+// UnmarshalJSON decodes a field mapping or a field name.
+func (m *FieldMapping) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*m = FieldMapping{}
+		return json.Unmarshal(data, &m.Field)
+	}
+
+	type plain FieldMapping
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// UnmarshalYAML decodes a field mapping or a field name with YAML
+// libraries supporting this interface, like gopkg.in/yaml.v2 and v3.
+func (m *FieldMapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*m = FieldMapping{}
+	if err := unmarshal(&m.Field); err == nil {
+		return nil
+	}
+
+	type plain FieldMapping
+	return unmarshal((*plain)(m))
+}
+
+// LoadMapping reads a JSON mapping file, or a YAML mapping file if the
+// path ends with ".yaml" or ".yml":
+//
+//	first_name: First Name
+//	amount: {field: Amount, type: number, format: "%.2f"}
+//	state:
+//	  field: State
+//	  lookup:
+//	    CA: California
+//
+// YAML files are decoded without dependencies, which supports the block
+// and flow styles of configuration files, but no anchors, aliases, tags
+// or multi-line plain scalars.
+func LoadMapping(path string) (Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML files are converted to JSON, so both are decoded alike.
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		doc, err := decodeYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the mapping file: %v", err)
+		}
+		data, err = json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the mapping file: %v", err)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var m Mapping
+	err = dec.Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the mapping file: %v", err)
+	}
+
+	err = m.validate()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// validate checks that no two keys are mapped to the same field,
+// because the filled value would depend on the map iteration order.
+func (m Mapping) validate() error {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mapped := make(map[string]string, len(m))
+	for _, key := range keys {
+		field := m[key].Field
+		if field == "" {
+			field = key
+		}
+		if other, ok := mapped[field]; ok {
+			return fmt.Errorf("keys '%s' and '%s' are both mapped to the field '%s'", other, key, field)
+		}
+		mapped[field] = key
+	}
+	return nil
+}
+
+// Apply returns a new form with the mapped values of the data.
+// The passed data is not modified. Mappings of several keys to the
+// same field fail.
+func (m Mapping) Apply(data Form) (Form, error) {
+	err := m.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	form := make(Form, len(m))
+	for key, fm := range m {
+		v, ok := data[key]
+		if !ok || v == nil || v == "" {
+			if fm.Expression != "" {
+				var err error
+				v, err = evalExpression(fm.Expression, data)
+				if err != nil {
+					return nil, fmt.Errorf("key '%s': expression: %v", key, err)
+				}
+				ok = true
+			}
+			if (v == nil || v == "") && fm.Default != nil {
+				v, ok = fm.Default, true
+			} else if !ok {
+				continue
+			}
+		}
+
+		if fm.Lookup != nil && formatValue(v) != "" {
+			s, found := fm.Lookup[formatValue(v)]
+			switch {
+			case found:
+				v = s
+			case fm.Default != nil:
+				v = fm.Default
+			default:
+				return nil, fmt.Errorf("key '%s': no lookup entry for '%s'", key, formatValue(v))
+			}
+		}
+
+		for _, name := range fm.Convert {
+			c, ok := lookupConverter(name)
+			if !ok {
+				return nil, fmt.Errorf("key '%s': unknown converter '%s'", key, name)
+			}
+			var err error
+			v, err = c(v)
+			if err != nil {
+				return nil, fmt.Errorf("key '%s': converter '%s': %v", key, name, err)
+			}
+		}
+
+		v, err := fm.convert(v)
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': %v", key, err)
+		}
+		if s := formatValue(v); fm.Width > 0 && s != "" && fm.Type != MappingCheckbox {
+			v, err = Pad(s, fm.Width, fm.Pad)
+			if err != nil {
+				return nil, fmt.Errorf("key '%s': %v", key, err)
+			}
+		}
+
+		field := fm.Field
+		if field == "" {
+			field = key
+		}
+		form[field] = v
+	}
+	return form, nil
+}
+
+// convert converts the value to the type of the mapping.
+// Empty values are kept.
+func (fm FieldMapping) convert(v interface{}) (interface{}, error) {
+	s := formatValue(v)
+	if strings.TrimSpace(s) == "" && fm.Type != MappingCheckbox {
+		return v, nil
+	}
+
+	switch fm.Type {
+	case "", MappingText:
+		return v, nil
+
+	case MappingNumber:
+		n, ok := toNumber(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid number: '%s'", s)
+		}
+		if fm.Format != "" {
+			return fmt.Sprintf(fm.Format, n), nil
+		}
+		return n, nil
+
+	case MappingDate:
+		t, ok := v.(time.Time)
+		if !ok {
+			var err error
+			t, err = parseDate(strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+		}
+		layout := fm.Format
+		if layout == "" {
+			layout = exprDateLayout
+		}
+		return t.Format(layout), nil
+
+	case MappingCheckbox:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "", "0", "false", "no", "off", "n":
+			return false, nil
+		case "1", "true", "yes", "on", "y", "x":
+			return true, nil
+		}
+		return nil, fmt.Errorf("invalid checkbox value: '%s'", s)
+
+	case MappingPhone:
+		return FormatPhone(s, fm.Country)
+
+	case MappingPostalCode:
+		return NormalizePostalCode(s, fm.Country)
+
+	default:
+		return nil, fmt.Errorf("unknown type '%s'", fm.Type)
+	}
+}
+
+// Pad pads the value to width characters with the padding PadRight,
+// PadLeft or PadZero. An empty padding defaults to PadRight.
+// Zero padding requires digits with optional decimal separators and
+// keeps the sign in front:
+//
+//	fillpdf.Pad("-42", 6, fillpdf.PadZero) // "-00042"
+func Pad(value string, width int, pad string) (string, error) {
+	n := utf8.RuneCountInString(value)
+	if n > width {
+		return "", fmt.Errorf("value '%s' exceeds %d characters", value, width)
+	}
+
+	switch pad {
+	case "", PadRight:
+		return value + strings.Repeat(" ", width-n), nil
+	case PadLeft:
+		return strings.Repeat(" ", width-n) + value, nil
+	case PadZero:
+		digits := strings.TrimLeft(value, "+-")
+		if digits == "" || strings.Trim(digits, "0123456789.,") != "" {
+			return "", fmt.Errorf("invalid number: '%s'", value)
+		}
+		sign := value[:len(value)-len(digits)]
+		return sign + strings.Repeat("0", width-n) + digits, nil
+	default:
+		return "", fmt.Errorf("unknown padding '%s'", pad)
+	}
+}
+
+// parseDate parses a date like "2006-01-02" or an RFC 3339 time.
+func parseDate(s string) (time.Time, error) {
+	t, err := time.Parse(exprDateLayout, s)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date: '%s'", s)
+}
@@ -0,0 +1,147 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"unicode"
+)
+
+// MaskedValuesAttachment is the name of the attachment containing
+// the encrypted full values of masked fields.
+const MaskedValuesAttachment = "masked-values.enc"
+
+// MaskFunc masks a form value.
+type MaskFunc func(value string) string
+
+// Mask replaces all letters and digits except the last keep ones with the
+// mask character. Separators are kept, so "123-45-6789" becomes "***-**-6789".
+func Mask(value string, keep int, mask rune) string {
+	r := []rune(value)
+	for i := len(r) - 1; i >= 0; i-- {
+		if !unicode.IsLetter(r[i]) && !unicode.IsDigit(r[i]) {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		r[i] = mask
+	}
+	return string(r)
+}
+
+// MaskSSN masks a social security number except the last four digits: ***-**-1234.
+func MaskSSN(value string) string {
+	return Mask(value, 4, '*')
+}
+
+// MaskCard masks a payment card number except the last four digits.
+func MaskCard(value string) string {
+	return Mask(value, 4, '*')
+}
+
+// maskValues applies the masks of the options to the form values.
+// The full values are returned encrypted if the options contain a mask key.
+// The passed form is not modified.
+func maskValues(form Form, opts Options) (Form, []byte, error) {
+	result := make(Form, len(form))
+	for k, v := range form {
+		result[k] = v
+	}
+
+	full := make(map[string]string)
+	for key, mask := range opts.Masks {
+		v, ok := form[key]
+		if !ok || v == nil {
+			continue
+		}
+		value := formatValue(v)
+		full[key] = value
+		result[key] = mask(value)
+	}
+
+	if len(opts.MaskKey) == 0 || len(full) == 0 {
+		return result, nil, nil
+	}
+
+	data, err := json.Marshal(full)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err = encryptAttachment(data, opts.MaskKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt the masked values: %v", err)
+	}
+
+	return result, data, nil
+}
+
+// DecryptMaskedValues decrypts the full values of the masked fields
+// from the data of the MaskedValuesAttachment.
+func DecryptMaskedValues(data []byte, key []byte) (map[string]string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted data")
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the masked values: %v", err)
+	}
+
+	var values map[string]string
+	err = json.Unmarshal(plain, &values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// encryptAttachment encrypts the data with AES-GCM.
+// The random nonce is prepended to the encrypted data.
+func encryptAttachment(data []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// memfdCreateTraps contains the memfd_create system call numbers, which
+// the syscall package does not define for all architectures.
+var memfdCreateTraps = map[string]uintptr{
+	"386":      356,
+	"amd64":    319,
+	"arm":      385,
+	"arm64":    279,
+	"loong64":  279,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+	"s390x":    350,
+}
+
+// mfdCloexec is the MFD_CLOEXEC flag of memfd_create.
+const mfdCloexec = 0x1
+
+// memFile returns an anonymous file in memory containing the data.
+// It returns nil if the system does not support memory files.
+func memFile(name string, data []byte) (*os.File, error) {
+	trap, ok := memfdCreateTraps[runtime.GOARCH]
+	if !ok {
+		return nil, nil
+	}
+
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(p)), mfdCloexec, 0)
+	if errno == syscall.ENOSYS {
+		return nil, nil
+	} else if errno != 0 {
+		return nil, os.NewSyscallError("memfd_create", errno)
+	}
+
+	file := os.NewFile(fd, "memfd:"+name)
+	_, err = file.Write(data)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,155 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// compositions are the precomposed characters of the Latin, Greek and
+// Cyrillic scripts by their combining mark. Every string lists pairs
+// of the base character and its composition with the mark.
+var compositions = map[rune]string{
+	0x0300: "AÀEÈIÌOÒUÙaàeèiìoòuùÜǛüǜNǸnǹЕЀИЍеѐиѝĒḔēḕŌṐōṑWẀwẁÂẦâầĂẰăằÊỀêềÔỒôồƠỜơờƯỪưừYỲyỳ",
+	0x0301: "AÁEÉIÍOÓUÚYÝaáeéiíoóuúyýCĆcćLĹlĺNŃnńRŔrŕSŚsśZŹzźÜǗüǘGǴgǵÅǺåǻÆǼæǽØǾøǿ¨΅ΑΆΕΈΗΉΙΊΟΌΥΎΩΏϊΐαάεέηήιίϋΰοόυύωώϒϓГЃКЌгѓкќÇḈçḉĒḖēḗÏḮïḯKḰkḱMḾmḿÕṌõṍŌṒōṓPṔpṕŨṸũṹWẂwẃÂẤâấĂẮăắÊẾêếÔỐôốƠỚơớƯỨưứ",
+	0x0302: "AÂEÊIÎOÔUÛaâeêiîoôuûCĈcĉGĜgĝHĤhĥJĴjĵSŜsŝWŴwŵYŶyŷZẐzẑẠẬạậẸỆẹệỌỘọộ",
+	0x0303: "AÃNÑOÕaãnñoõIĨiĩUŨuũVṼvṽÂẪâẫĂẴăẵEẼeẽÊỄêễÔỖôỗƠỠơỡƯỮưữYỸyỹ",
+	0x0304: "AĀaāEĒeēIĪiīOŌoōUŪuūÜǕüǖÄǞäǟȦǠȧǡÆǢæǣǪǬǫǭÖȪöȫÕȬõȭȮȰȯȱYȲyȳИӢиӣУӮуӯGḠgḡḶḸḷḹṚṜṛṝ",
+	0x0306: "AĂaăEĔeĕGĞgğIĬiĭOŎoŏUŬuŭУЎИЙийуўЖӁжӂАӐаӑЕӖеӗȨḜȩḝẠẶạặ",
+	0x0307: "CĊcċEĖeėGĠgġIİZŻzżAȦaȧOȮoȯBḂbḃDḊdḋFḞfḟHḢhḣMṀmṁNṄnṅPṖpṗRṘrṙSṠsṡŚṤśṥŠṦšṧṢṨṣṩTṪtṫWẆwẇXẊxẋYẎyẏſẛ",
+	0x0308: "AÄEËIÏOÖUÜaäeëiïoöuüyÿYŸΙΪΥΫιϊυϋϒϔЕЁІЇеёіїАӒаӓӘӚәӛЖӜжӝЗӞзӟИӤиӥОӦоӧӨӪөӫЭӬэӭУӰуӱЧӴчӵЫӸыӹHḦhḧÕṎõṏŪṺūṻWẄwẅXẌxẍtẗ",
+	0x0309: "AẢaảÂẨâẩĂẲăẳEẺeẻÊỂêểIỈiỉOỎoỏÔỔôổƠỞơởUỦuủƯỬưửYỶyỷ",
+	0x030A: "AÅaåUŮuůwẘyẙ",
+	0x030B: "OŐoőUŰuűУӲуӳ",
+	0x030C: "CČcčDĎdďEĚeěLĽlľNŇnňRŘrřSŠsšTŤtťZŽzžAǍaǎIǏiǐOǑoǒUǓuǔÜǙüǚGǦgǧKǨkǩƷǮjǰHȞhȟ",
+	0x030F: "AȀaȁEȄeȅIȈiȉOȌoȍRȐrȑUȔuȕѴѶѵѷ",
+	0x0311: "AȂaȃEȆeȇIȊiȋOȎoȏRȒrȓUȖuȗ",
+	0x031B: "OƠoơUƯuư",
+	0x0323: "BḄbḅDḌdḍHḤhḥKḲkḳLḶlḷMṂmṃNṆnṇRṚrṛSṢsṣTṬtṭVṾvṿWẈwẉZẒzẓAẠaạEẸeẹIỊiịOỌoọƠỢơợUỤuụƯỰưựYỴyỵ",
+	0x0324: "UṲuṳ",
+	0x0325: "AḀaḁ",
+	0x0326: "SȘsșTȚtț",
+	0x0327: "CÇcçGĢgģKĶkķLĻlļNŅnņRŖrŗSŞsşTŢtţEȨeȩDḐdḑHḨhḩ",
+	0x0328: "AĄaąEĘeęIĮiįUŲuųOǪoǫ",
+	0x032D: "DḒdḓEḘeḙLḼlḽNṊnṋTṰtṱUṶuṷ",
+	0x032E: "HḪhḫ",
+	0x0330: "EḚeḛIḬiḭUṴuṵ",
+	0x0331: "BḆbḇDḎdḏKḴkḵLḺlḻNṈnṉRṞrṟTṮtṯZẔzẕhẖ",
+}
+
+// ligatures are the compatibility decompositions of the Latin ligatures.
+var ligatures = map[rune]string{
+	'ﬀ': "ff",
+	'ﬁ': "fi",
+	'ﬂ': "fl",
+	'ﬃ': "ffi",
+	'ﬄ': "ffl",
+	'ﬅ': "st",
+	'ﬆ': "st",
+}
+
+// NormalizeNFC composes base characters followed by combining marks into
+// their precomposed characters, like "é" into "é", so copy-pasted
+// values render the same in every viewer. It covers the Latin, Greek and
+// Cyrillic scripts, other sequences are kept.
+func NormalizeNFC(name, value string) (string, error) {
+	if !hasCombiningMark(value) {
+		return value, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(value))
+
+	var base rune = -1
+	for _, r := range value {
+		if base >= 0 {
+			if c, ok := compose(base, r); ok {
+				base = c
+				continue
+			}
+			b.WriteRune(base)
+		}
+		base = r
+	}
+	if base >= 0 {
+		b.WriteRune(base)
+	}
+	return b.String(), nil
+}
+
+// NormalizeNFKC normalizes the value like NormalizeNFC and replaces
+// compatibility characters by their plain equivalents: fullwidth ASCII
+// characters, spaces like the no-break space and the Latin ligatures.
+func NormalizeNFKC(name, value string) (string, error) {
+	value, _ = NormalizeNFC(name, value)
+
+	var b strings.Builder
+	b.Grow(len(value))
+	for _, r := range value {
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			b.WriteRune(r - 0xFEE0)
+		case unicode.Is(unicode.Zs, r):
+			b.WriteByte(' ')
+		case ligatures[r] != "":
+			b.WriteString(ligatures[r])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+// StripInvisible removes invisible format characters, like zero-width
+// spaces and joiners, soft hyphens, byte order marks and bidirectional
+// marks, which are often copied along with values.
+func StripInvisible(name, value string) (string, error) {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, value), nil
+}
+
+// hasCombiningMark returns whether the value contains a combining mark.
+func hasCombiningMark(value string) bool {
+	return strings.IndexFunc(value, func(r rune) bool {
+		return r >= 0x0300 && r <= 0x036F
+	}) >= 0
+}
+
+// compose returns the precomposed character of the base and the mark.
+func compose(base, mark rune) (rune, bool) {
+	pairs, ok := compositions[mark]
+	if !ok {
+		return 0, false
+	}
+	for i := 0; i < len(pairs); {
+		b, n := utf8.DecodeRuneInString(pairs[i:])
+		c, m := utf8.DecodeRuneInString(pairs[i+n:])
+		if b == base {
+			return c, true
+		}
+		i += n + m
+	}
+	return 0, false
+}
@@ -0,0 +1,220 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+// Options defines optional settings for filling a PDF form.
+type Options struct {
+	// Fast enables the performance mode.
+	// The form PDF file is passed as is to pdftk without resolving
+	// its absolute path and checking if it exists.
+	Fast bool
+
+	// Validate checks the form values against the form fields before
+	// filling, like the character count of comb fields.
+	// A ValidationError is returned for invalid values.
+	Validate bool
+
+	// TemplateData is the data context to render form values of the type
+	// *template.Template (text/template).
+	TemplateData interface{}
+
+	// TemplateStrings enables rendering string form values as
+	// text/template templates, like "{{.First}} {{.Last}}".
+	TemplateStrings bool
+
+	// Flatten merges the form fields into the page contents, so the
+	// output is not fillable anymore. pdftk flattens all fields.
+	// To keep a subset of the fields interactive, like a signature field,
+	// set the other fields read-only with the Flags option instead.
+	Flatten bool
+
+	// ReplacementFont is the path of a TrueType font, which pdftk uses to
+	// generate the appearances of values containing characters missing in
+	// the fonts of the form. It requires pdftk-java 3.1 or later.
+	// Characters missing in the replacement font, too, are replaced by
+	// the Transliterate value policy.
+	ReplacementFont string
+
+	// ReadOnly makes all filled fields read-only without flattening the form.
+	ReadOnly bool
+
+	// Flags sets and clears the flags of fields in the output.
+	// The key is the field name. Use this to hide internal fields with
+	// the AnnotFlagHidden flag or to mark fields as required.
+	// The flags are passed with the /SetFf, /ClrFf, /SetF and /ClrF
+	// entries of the FDF data.
+	Flags map[string]FieldFlags
+
+	// TemplateSHA256 is the expected hex encoded SHA-256 checksum of
+	// the form PDF file. Fills of modified files fail with
+	// ErrTemplateModified. The file is read and hashed on every fill.
+	TemplateSHA256 string
+
+	// Mapping maps the keys of the form values to the form fields
+	// before all other options are applied. See LoadMapping.
+	Mapping Mapping
+
+	// Rules derive form values from other form values.
+	// They are evaluated in order before the form is filled.
+	Rules Rules
+
+	// Expressions compute form values from other form values.
+	// They are evaluated after the rules.
+	Expressions Expressions
+
+	// Appendix contains text sections printed on continuation pages
+	// which are appended to the filled PDF file.
+	Appendix []Section
+
+	// Attachments are embedded as files into the filled PDF file.
+	Attachments []Attachment
+
+	// ViewerPreferences are print hints stored in the filled PDF file.
+	ViewerPreferences *ViewerPreferences
+
+	// InitialView is the view of the filled PDF file when it is opened.
+	InitialView *InitialView
+
+	// Lang is the natural language of the filled PDF file, like "en-US",
+	// which is read by screen readers. It is set as /Lang entry of the
+	// document catalog.
+	Lang string
+
+	// Stamp prints a footer with the generation time, user, document ID
+	// and hash of the form values on every page.
+	Stamp *Stamp
+
+	// EmbedDocumentID embeds a document ID into the document information
+	// of the filled PDF file. If the ContentKey is set, an HMAC-SHA256
+	// of the field values of the filled PDF file is embedded, too.
+	// Use VerifyContentHash to detect modified documents later.
+	EmbedDocumentID bool
+
+	// DocumentID is the embedded document ID.
+	// Defaults to a random UUID.
+	DocumentID string
+
+	// ContentKey is the secret key of the embedded content hash,
+	// so the hash of modified documents can't be recomputed without it.
+	ContentKey []byte
+
+	// UserPassword encrypts the filled PDF file with AES-128 and the
+	// password, which is required to open it. The password is passed to
+	// pdftk through stdin, so it does not show up in the process list.
+	UserPassword string
+
+	// Permissions are granted to the recipients of encrypted PDF files.
+	// Defaults to PermissionPrinting. The owner password, which is
+	// required to change them, is random.
+	Permissions []Permission
+
+	// Masks replaces the form values of the fields with masked values,
+	// like MaskSSN. The key is the field name.
+	Masks map[string]MaskFunc
+
+	// MaskKey is a 16, 24 or 32 byte AES key. If set, the full values of the
+	// masked fields are encrypted with the key and embedded as
+	// MaskedValuesAttachment. Use DecryptMaskedValues to read them.
+	MaskKey []byte
+
+	// Cache returns the filled PDF file of an identical earlier fill
+	// of the same template, form values and options. The key is derived
+	// from the prepared field values, so changed encoders, converters or
+	// dates result in new entries. Fills using provenance records, masks,
+	// stamps, encryption or generated document IDs are not cached.
+	Cache Cache
+
+	// OnFill is called after every fill with an audit event.
+	OnFill func(FillEvent)
+
+	// RequestID is passed to the OnFill callback.
+	RequestID string
+
+	// Signed defines how digitally signed PDF files are handled.
+	// Filling rewrites the whole file, which invalidates existing signatures.
+	// Defaults to SignedIgnore, which fills without checking.
+	Signed SignedPolicy
+
+	// Tenant identifies the caller for the TenantLimiter of the Filler.
+	Tenant string
+
+	// ValuePolicy checks and sanitizes every text value before the fill.
+	// Defaults to DefaultValuePolicy. Policies can normalize copy-pasted
+	// values, too:
+	//
+	//	fillpdf.Policies(fillpdf.DefaultValuePolicy, fillpdf.NormalizeNFC, fillpdf.StripInvisible)
+	ValuePolicy ValuePolicy
+
+	// Priority orders the fill among the fills waiting for a free slot
+	// of a Filler limited by MaxConcurrent.
+	Priority Priority
+
+	// HashAuditValues adds HMAC-SHA256 hashes of the form values to
+	// the events passed to the OnFill callback. It requires the AuditKey
+	// of the FillerConfig.
+	HashAuditValues bool
+
+	// Provenance embeds a record of which system and user filled each
+	// field as ProvenanceAttachment JSON file.
+	Provenance *Provenance
+
+	// SpillLongValues moves text values exceeding the maximum length of their
+	// field to a section of the appendix. The field is filled with the
+	// SpillReference text instead, so no data is lost silently. Fields
+	// shorter than the reference are filled with an asterisk. Comb fields
+	// are not spilled: the Validate option, which runs first, rejects
+	// their long values, and without it pdftk clips them.
+	SpillLongValues bool
+
+	// SpillTitle is the title of the appendix section containing the long values.
+	// Defaults to "Attachment A".
+	SpillTitle string
+
+	// SpillReference is filled into fields with long values.
+	// Defaults to "See attachment A".
+	SpillReference string
+
+	// stampText is the rendered stamp of the fill.
+	stampText string
+}
+
+// needsFields returns whether the options require the form fields.
+func (o Options) needsFields() bool {
+	return o.Validate || o.SpillLongValues
+}
+
+// outputArgs returns the pdftk output options.
+func (o Options) outputArgs() []string {
+	var args []string
+	if o.Flatten {
+		args = append(args, "flatten")
+	}
+	if o.ReplacementFont != "" {
+		args = append(args, "replacement_font", safePath(o.ReplacementFont))
+	}
+	return args
+}
+
+// needsFinish returns whether the options require post-processing
+// of the filled PDF file.
+func (o Options) needsFinish() bool {
+	return len(o.Appendix) > 0 || len(o.Attachments) > 0 || o.Stamp != nil || o.stampText != "" ||
+		o.ViewerPreferences != nil || o.InitialView != nil || o.Lang != "" || o.EmbedDocumentID ||
+		o.UserPassword != ""
+}
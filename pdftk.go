@@ -0,0 +1,268 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxStderrSize limits the error output kept in a PdftkError.
+const maxStderrSize = 64 * 1024
+
+// PdftkError is returned for failed pdftk calls.
+type PdftkError struct {
+	// Args are the arguments of the call. Passwords are redacted.
+	Args []string
+
+	// ExitCode is the exit code of pdftk, or -1 if it was killed
+	// or did not start.
+	ExitCode int
+
+	// Stderr is the beginning of the error output of pdftk.
+	Stderr string
+
+	Duration time.Duration
+
+	// Err is the error of the process, like a context.DeadlineExceeded
+	// error for timeouts.
+	Err error
+}
+
+func (e *PdftkError) Error() string {
+	return fmt.Sprintf("pdftk error: %v\nOutput: %s", e.Err, e.Stderr)
+}
+
+// Unwrap returns the error of the process.
+func (e *PdftkError) Unwrap() error {
+	return e.Err
+}
+
+// redactArgs returns the arguments with the passwords replaced.
+func redactArgs(args []string) []string {
+	redacted := append([]string(nil), args...)
+	for i := 1; i < len(redacted); i++ {
+		switch redacted[i-1] {
+		case "user_pw", "owner_pw", "input_pw":
+			redacted[i] = "***"
+		}
+	}
+	return redacted
+}
+
+// SetCommand sets the command used to invoke pdftk.
+// The optional args are prepended to the arguments of every pdftk call.
+// This allows to route all calls through a long-lived helper process,
+// like a nailgun server hosting pdftk-java, which avoids the JVM
+// startup cost on every fill:
+//
+//	fillpdf.SetCommand("ng", "com.gitlab.pdftk_java.pdftk")
+func SetCommand(name string, args ...string) {
+	DefaultFiller.SetCommand(name, args...)
+}
+
+// SetCommand sets the command used by the filler to invoke pdftk.
+// See the package-level SetCommand.
+func (f *Filler) SetCommand(name string, args ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.name = name
+	f.args = append([]string(nil), args...)
+	f.path = ""
+}
+
+// hasCommandPrefix returns true if the pdftk calls are routed through a
+// command set with SetCommand, which may not pass file descriptors on.
+func (f *Filler) hasCommandPrefix() bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return len(f.args) > 0
+}
+
+// command creates a new pdftk command with the specified arguments.
+func (f *Filler) command(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	f.mutex.RLock()
+	name, path := f.name, f.path
+	cmdArgs := append(append([]string(nil), f.args...), args...)
+	f.mutex.RUnlock()
+
+	if path == "" {
+		// Check if the pdftk utility exists.
+		var err error
+		path, err = exec.LookPath(name)
+		if err != nil {
+			return nil, fmt.Errorf("pdftk utility is not installed!")
+		}
+
+		f.mutex.Lock()
+		if f.name == name {
+			f.path = path
+		}
+		f.mutex.Unlock()
+	}
+
+	return exec.CommandContext(ctx, path, cmdArgs...), nil
+}
+
+// resetCommandPath drops the cached executable path, so the next command
+// resolves it again. Call this if a pdftk call failed.
+func (f *Filler) resetCommandPath() {
+	f.mutex.Lock()
+	f.path = ""
+	f.mutex.Unlock()
+}
+
+// RunPdftk runs pdftk with the arguments, like operations the package
+// does not wrap, with the command, limits and timeout of the filler.
+// The input is read from stdin, which may be nil, and the output is
+// streamed to the returned reader. Errors of the call, like a
+// *PdftkError, are returned by Read after the output.
+// Read the output to the end or cancel the context, otherwise the
+// process is blocked. Canceling the context kills the process.
+func RunPdftk(ctx context.Context, args []string, stdin io.Reader) (stdout io.Reader, err error) {
+	return DefaultFiller.RunPdftk(ctx, args, stdin)
+}
+
+// RunPdftk runs pdftk with the arguments. See the package-level RunPdftk.
+func (f *Filler) RunPdftk(ctx context.Context, args []string, stdin io.Reader) (stdout io.Reader, err error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing pdftk arguments")
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(f.runContext(ctx, PriorityNormal, stdin, pw, nil, args...))
+	}()
+	return pr, nil
+}
+
+// run runs pdftk with the arguments, reading from stdin and writing to
+// stdout. Both may be nil. Failed calls return a *PdftkError.
+func (f *Filler) run(p Priority, stdin io.Reader, stdout io.Writer, args ...string) error {
+	return f.runContext(context.Background(), p, stdin, stdout, nil, args...)
+}
+
+// runContext runs pdftk like run. The process is killed if the context
+// is done. The files are passed to the process as file descriptors
+// starting at 3, see exec.Cmd.ExtraFiles.
+func (f *Filler) runContext(callCtx context.Context, p Priority, stdin io.Reader, stdout io.Writer, files []*os.File, args ...string) error {
+	err := f.begin()
+	if err != nil {
+		return err
+	}
+	defer f.end()
+
+	ctx := f.ctx
+	if callCtx.Done() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		defer context.AfterFunc(callCtx, cancel)()
+	}
+
+	if f.sched != nil {
+		if !f.sched.acquire(p, ctx.Done()) {
+			if callCtx.Err() != nil {
+				return callCtx.Err()
+			}
+			return ErrClosed
+		}
+		defer f.sched.release()
+	}
+
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+
+	cmd, err := f.command(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	stderr := &limitedBuffer{max: maxStderrSize}
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = files
+
+	start := time.Now()
+	err = cmd.Run()
+	duration := time.Since(start)
+	if f.logger != nil {
+		f.logger.Printf("pdftk %s: %v (%v)", strings.Join(redactArgs(args), " "), duration, err)
+	}
+	if err != nil {
+		if f.ctx.Err() != nil {
+			// The process was killed by Close.
+			return ErrClosed
+		}
+		f.resetCommandPath()
+
+		exitCode := -1
+		if cmd.ProcessState != nil {
+			exitCode = cmd.ProcessState.ExitCode()
+		}
+		if callCtx.Err() != nil {
+			err = callCtx.Err()
+		} else if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return &PdftkError{
+			Args:     redactArgs(args),
+			ExitCode: exitCode,
+			Stderr:   stderr.String(),
+			Duration: duration,
+			Err:      err,
+		}
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first max bytes written to it
+// and discards the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.Len(); n > 0 {
+		b.Buffer.Write(p[:min(n, len(p))])
+	}
+	return len(p), nil
+}
+
+// output runs pdftk with the arguments and returns its output.
+func (f *Filler) output(p Priority, stdin io.Reader, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := f.run(p, stdin, &stdout, args...)
+	if err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,248 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Plan is a prepared fill of a form PDF file. It contains the resolved
+// text values of the fields after the mapping, encoders, rules,
+// expressions and value policies are applied, and the operations on the
+// filled PDF file. Plans can be inspected, logged and stored as JSON
+// before they are executed with ExecutePlan.
+type Plan struct {
+	// Template is the absolute path of the form PDF file.
+	Template string `json:"template"`
+
+	// TemplateSHA256 is the hex encoded SHA-256 checksum of the form PDF
+	// file when the plan was created. The plan fails to execute with
+	// ErrTemplateModified if the file has changed since.
+	TemplateSHA256 string `json:"template_sha256,omitempty"`
+
+	// Values are the text values by field name.
+	Values map[string]string `json:"values"`
+
+	Flags           map[string]FieldFlags `json:"flags,omitempty"`
+	ReadOnly        bool                  `json:"read_only,omitempty"`
+	Flatten         bool                  `json:"flatten,omitempty"`
+	ReplacementFont string                `json:"replacement_font,omitempty"`
+
+	// Appendix are the appended pages, including spilled values.
+	Appendix []Section `json:"appendix,omitempty"`
+
+	// Stamp is the rendered stamp text.
+	Stamp string `json:"stamp,omitempty"`
+
+	// Attachments include generated attachments, like masked values
+	// and provenance records.
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// DocumentID is embedded into the document information if it is set.
+	DocumentID string `json:"document_id,omitempty"`
+
+	// ContentKey is the key of the embedded content hash. It is not
+	// stored in JSON, so set it again after decoding a plan.
+	ContentKey []byte `json:"-"`
+
+	ViewerPreferences *ViewerPreferences `json:"viewer_preferences,omitempty"`
+	InitialView       *InitialView       `json:"initial_view,omitempty"`
+	Lang              string             `json:"lang,omitempty"`
+
+	// UserPassword encrypts the filled PDF file. It is not stored in JSON,
+	// so set it again after decoding a plan.
+	UserPassword string `json:"-"`
+
+	// Permissions are granted to the recipients of the encrypted file.
+	Permissions []Permission `json:"permissions,omitempty"`
+
+	Priority Priority `json:"priority,omitempty"`
+
+	// Tenant identifies the caller for the TenantLimiter of the Filler.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// PlanFill prepares the fill of the form PDF file with the form values
+// and options without running pdftk, so the result can be inspected or
+// cached before it is executed with ExecutePlan.
+// The Cache and OnFill options apply to fills only and are ignored.
+// Signed form PDF files fail with ErrAlreadySigned unless the Signed
+// option is SignedIgnore or SignedProceed.
+func PlanFill(form Form, formPDFFile string, opts Options) (*Plan, error) {
+	return DefaultFiller.PlanFill(form, formPDFFile, opts)
+}
+
+// PlanFill prepares the fill of the form PDF file.
+// See the package-level PlanFill.
+func (f *Filler) PlanFill(form Form, formPDFFile string, opts Options) (*Plan, error) {
+	formPDFFile, err := resolveFormFile(formPDFFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(formPDFFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the form PDF file: %v", err)
+	}
+	if opts.TemplateSHA256 != "" {
+		err = verifyChecksum(data, opts.TemplateSHA256)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Signed == SignedUnchanged {
+		opts.Signed = SignedError
+	}
+	_, err = f.checkSigned(data, formPDFFile, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	form, opts, err = prepareForm(form, opts, func() ([]Field, error) {
+		return f.ReadFieldsFromReader(bytes.NewReader(data))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	p := &Plan{
+		Template:          formPDFFile,
+		TemplateSHA256:    hex.EncodeToString(sum[:]),
+		Values:            make(map[string]string, len(form)),
+		Flags:             opts.Flags,
+		ReadOnly:          opts.ReadOnly,
+		Flatten:           opts.Flatten,
+		ReplacementFont:   opts.ReplacementFont,
+		Appendix:          opts.Appendix,
+		Stamp:             opts.stampText,
+		Attachments:       opts.Attachments,
+		ViewerPreferences: opts.ViewerPreferences,
+		InitialView:       opts.InitialView,
+		Lang:              opts.Lang,
+		UserPassword:      opts.UserPassword,
+		Permissions:       opts.Permissions,
+		Priority:          opts.Priority,
+		Tenant:            opts.Tenant,
+	}
+	for k, v := range form {
+		p.Values[k] = formatValue(v)
+	}
+	if opts.EmbedDocumentID {
+		p.DocumentID, p.ContentKey = opts.DocumentID, opts.ContentKey
+	}
+	return p, nil
+}
+
+// Operations returns the pdftk operations of the plan in the order they
+// are executed, like "fill_form", "flatten" and "encrypt".
+func (p *Plan) Operations() []string {
+	ops := []string{"fill_form"}
+	if p.Flatten {
+		ops = append(ops, "flatten")
+	}
+	if len(p.Appendix) > 0 {
+		ops = append(ops, "append_pages")
+	}
+	if p.Stamp != "" {
+		ops = append(ops, "stamp")
+	}
+	if len(p.Attachments) > 0 {
+		ops = append(ops, "attach_files")
+	}
+	if p.DocumentID != "" {
+		ops = append(ops, "update_info")
+	}
+	if p.ViewerPreferences != nil || p.InitialView != nil || p.Lang != "" {
+		ops = append(ops, "update_catalog")
+	}
+	if p.UserPassword != "" {
+		ops = append(ops, "encrypt")
+	}
+	return ops
+}
+
+// options returns the prepared options of the plan.
+func (p *Plan) options() Options {
+	return Options{
+		Flags:             p.Flags,
+		ReadOnly:          p.ReadOnly,
+		Flatten:           p.Flatten,
+		ReplacementFont:   p.ReplacementFont,
+		Appendix:          p.Appendix,
+		Attachments:       p.Attachments,
+		EmbedDocumentID:   p.DocumentID != "",
+		DocumentID:        p.DocumentID,
+		ContentKey:        p.ContentKey,
+		ViewerPreferences: p.ViewerPreferences,
+		InitialView:       p.InitialView,
+		Lang:              p.Lang,
+		UserPassword:      p.UserPassword,
+		Permissions:       p.Permissions,
+		Priority:          p.Priority,
+		Tenant:            p.Tenant,
+		stampText:         p.Stamp,
+	}
+}
+
+// ExecutePlan fills the form PDF file of the plan with its values and
+// writes the filled PDF file to w. The plan is not modified, so it can
+// be executed again. Executions count towards the TenantLimiter of the
+// Filler like fills.
+func ExecutePlan(w io.Writer, p *Plan) error {
+	return DefaultFiller.ExecutePlan(w, p)
+}
+
+// ExecutePlan executes the plan. See the package-level ExecutePlan.
+func (f *Filler) ExecutePlan(w io.Writer, p *Plan) error {
+	opts := p.options()
+
+	release, err := f.acquireQuota(opts)
+	if err != nil {
+		return err
+	}
+	cw := &countingWriter{w: w}
+	defer func() {
+		release(cw.n)
+	}()
+
+	// The checked data is filled, because the file may be replaced
+	// before pdftk reads it.
+	data, err := os.ReadFile(p.Template)
+	if err != nil {
+		return fmt.Errorf("failed to read the form PDF file: %v", err)
+	}
+	if p.TemplateSHA256 != "" {
+		err = verifyChecksum(data, p.TemplateSHA256)
+		if err != nil {
+			return err
+		}
+	}
+
+	form := make(Form, len(p.Values))
+	for k, v := range p.Values {
+		form[k] = v
+	}
+	return f.execute(cw, "-", false, bytes.NewReader(data), form, opts)
+}
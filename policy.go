@@ -0,0 +1,173 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValuePolicy checks and sanitizes the text value of a form field.
+// It returns the value to fill or an error rejecting the fill.
+type ValuePolicy func(name, value string) (string, error)
+
+// DefaultValuePolicy removes null bytes and control characters except
+// tabs and line breaks, and replaces invalid UTF-8 sequences.
+// It is used if the ValuePolicy option is not set.
+func DefaultValuePolicy(name, value string) (string, error) {
+	value = strings.ToValidUTF8(value, "\uFFFD")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, value), nil
+}
+
+// RejectControlCharacters rejects values containing null bytes, control
+// characters except tabs and line breaks or invalid UTF-8 sequences.
+func RejectControlCharacters(name, value string) (string, error) {
+	if !utf8.ValidString(value) {
+		return "", fmt.Errorf("field '%s': invalid UTF-8 value", name)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return "", fmt.Errorf("field '%s': control character %U", name, r)
+		}
+	}
+	return value, nil
+}
+
+// PrintableOnly rejects values containing characters which are not printable,
+// including line breaks.
+func PrintableOnly(name, value string) (string, error) {
+	for _, r := range value {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("field '%s': non-printable character %U", name, r)
+		}
+	}
+	return value, nil
+}
+
+// MaxValueLength returns a policy rejecting values longer than n characters.
+func MaxValueLength(n int) ValuePolicy {
+	return func(name, value string) (string, error) {
+		if utf8.RuneCountInString(value) > n {
+			return "", fmt.Errorf("field '%s': value exceeds %d characters", name, n)
+		}
+		return value, nil
+	}
+}
+
+// Policies returns a policy applying the policies in order.
+func Policies(policies ...ValuePolicy) ValuePolicy {
+	return func(name, value string) (string, error) {
+		var err error
+		for _, p := range policies {
+			value, err = p(name, value)
+			if err != nil {
+				return "", err
+			}
+		}
+		return value, nil
+	}
+}
+
+// GlyphPolicy defines how characters outside of the Basic Multilingual
+// Plane, like emoji, are handled. The fonts of most forms lack them.
+type GlyphPolicy int
+
+const (
+	// GlyphKeep fills the characters unchanged.
+	GlyphKeep GlyphPolicy = iota
+
+	// GlyphStrip removes the characters.
+	GlyphStrip
+
+	// GlyphReplace replaces every character by a question mark.
+	GlyphReplace
+)
+
+// SupplementaryCharacters returns a policy handling characters outside
+// of the Basic Multilingual Plane, like emoji, according to p.
+// Emoji sequences, which combine several characters with zero width
+// joiners, variation selectors and skin tone modifiers, are handled
+// as one character.
+func SupplementaryCharacters(p GlyphPolicy) ValuePolicy {
+	return func(name, value string) (string, error) {
+		if p == GlyphKeep {
+			return value, nil
+		}
+
+		var (
+			b        strings.Builder
+			inSeq    bool
+			afterZWJ bool
+		)
+		for _, r := range value {
+			switch {
+			case inSeq && (afterZWJ || isSkinToneModifier(r)):
+				afterZWJ = false
+			case r > 0xFFFF:
+				// Every other supplementary character starts a new sequence.
+				if p == GlyphReplace {
+					b.WriteByte('?')
+				}
+				inSeq, afterZWJ = true, false
+			case inSeq && r == '\u200D':
+				afterZWJ = true
+			case inSeq && (r == '\uFE0E' || r == '\uFE0F'):
+			default:
+				inSeq, afterZWJ = false, false
+				b.WriteRune(r)
+			}
+		}
+		return b.String(), nil
+	}
+}
+
+// isSkinToneModifier returns whether r is an emoji skin tone modifier,
+// which modifies the preceding emoji.
+func isSkinToneModifier(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+// applyValuePolicy applies the policy of the options to the text values
+// of the form. The passed form is not modified.
+func applyValuePolicy(form Form, opts Options) (Form, error) {
+	policy := opts.ValuePolicy
+	if policy == nil {
+		policy = DefaultValuePolicy
+	}
+
+	result := make(Form, len(form))
+	for k, v := range form {
+		if s, ok := v.(string); ok {
+			var err error
+			v, err = policy(k, s)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result[k] = v
+	}
+	return result, nil
+}
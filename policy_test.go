@@ -0,0 +1,80 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSupplementaryCharacters(t *testing.T) {
+	tests := []struct {
+		value   string
+		strip   string
+		replace string
+	}{
+		{"abc", "abc", "abc"},
+		{"äöü€", "äöü€", "äöü€"},
+		{"a😀b", "ab", "a?b"},
+		{"😀😀", "", "??"},
+		{"👍🏽", "", "?"},
+		{"👍🏽👍", "", "??"},
+		{"❤️", "❤️", "❤️"},
+		{"😀️!", "!", "?!"},
+		{"👩‍💻 ok", " ok", "? ok"},
+		{"👨‍👩‍👧‍👦👍", "", "??"},
+		{"🏃‍♀️", "", "?"},
+		{"a‍b", "a‍b", "a‍b"},
+	}
+	for _, tt := range tests {
+		got, err := SupplementaryCharacters(GlyphStrip)("f", tt.value)
+		if err != nil || got != tt.strip {
+			t.Errorf("strip %q: got %q, %v, want %q", tt.value, got, err, tt.strip)
+		}
+		got, err = SupplementaryCharacters(GlyphReplace)("f", tt.value)
+		if err != nil || got != tt.replace {
+			t.Errorf("replace %q: got %q, %v, want %q", tt.value, got, err, tt.replace)
+		}
+		got, err = SupplementaryCharacters(GlyphKeep)("f", tt.value)
+		if err != nil || got != tt.value {
+			t.Errorf("keep %q: got %q, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestAppendUTF16(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []byte
+	}{
+		{"", []byte{0xfe, 0xff}},
+		{"a", []byte{0xfe, 0xff, 0x00, 0x61}},
+		{"ä€", []byte{0xfe, 0xff, 0x00, 0xe4, 0x20, 0xac}},
+		{"a😀", []byte{0xfe, 0xff, 0x00, 0x61, 0xd8, 0x3d, 0xde, 0x00}},
+		{"()", []byte{0xfe, 0xff, 0x00, '\\', '(', 0x00, '\\', ')'}},
+		{"\\\n", []byte{0xfe, 0xff, 0x00, '\\', '\\', 0x00, '\\', '0', '1', '2'}},
+		{"\u0A28", []byte{0xfe, 0xff, '\\', '0', '1', '2', '\\', '('}},
+	}
+	for _, tt := range tests {
+		got := appendUTF16(nil, tt.value, true)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%q: got % x, want % x", tt.value, got, tt.want)
+		}
+	}
+}
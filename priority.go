@@ -0,0 +1,127 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"sync"
+)
+
+// Priority orders fills waiting for a free slot of a Filler
+// limited by MaxConcurrent. Running pdftk processes are not interrupted.
+type Priority int
+
+// Priorities of fills.
+const (
+	// PriorityLow is meant for batch and mail-merge jobs, which only run
+	// if no fills of a higher priority are waiting.
+	PriorityLow Priority = -1
+
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+
+	// PriorityHigh is meant for interactive requests.
+	PriorityHigh Priority = 1
+)
+
+// scheduler hands out a limited number of slots to the waiting
+// callers in the order of their priority.
+type scheduler struct {
+	mutex   sync.Mutex
+	max     int
+	running int
+
+	// waiting holds the queues of the low, normal and high priorities.
+	waiting [3][]chan struct{}
+}
+
+func newScheduler(max int) *scheduler {
+	return &scheduler{
+		max: max,
+	}
+}
+
+// acquire waits for a free slot. It returns false if done is closed first.
+// A successful call must be followed by a call to release.
+func (s *scheduler) acquire(p Priority, done <-chan struct{}) bool {
+	i := int(p - PriorityLow)
+	if i < 0 {
+		i = 0
+	} else if i >= len(s.waiting) {
+		i = len(s.waiting) - 1
+	}
+
+	s.mutex.Lock()
+	if s.running < s.max && s.idle() {
+		s.running++
+		s.mutex.Unlock()
+		return true
+	}
+	ch := make(chan struct{})
+	s.waiting[i] = append(s.waiting[i], ch)
+	s.mutex.Unlock()
+
+	select {
+	case <-ch:
+		return true
+	case <-done:
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for j, c := range s.waiting[i] {
+		if c == ch {
+			s.waiting[i] = append(s.waiting[i][:j], s.waiting[i][j+1:]...)
+			return false
+		}
+	}
+
+	// The slot was handed over concurrently.
+	s.releaseLocked()
+	return false
+}
+
+// release hands the slot over to the waiting caller
+// with the highest priority.
+func (s *scheduler) release() {
+	s.mutex.Lock()
+	s.releaseLocked()
+	s.mutex.Unlock()
+}
+
+func (s *scheduler) releaseLocked() {
+	for i := len(s.waiting) - 1; i >= 0; i-- {
+		if len(s.waiting[i]) > 0 {
+			ch := s.waiting[i][0]
+			s.waiting[i] = s.waiting[i][1:]
+			close(ch)
+			return
+		}
+	}
+	s.running--
+}
+
+// idle returns whether no callers are waiting.
+func (s *scheduler) idle() bool {
+	for _, w := range s.waiting {
+		if len(w) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// ProvenanceAttachment is the name of the attachment containing the provenance record.
+const ProvenanceAttachment = "provenance.json"
+
+// Provenance records which system and user filled the fields of a form.
+type Provenance struct {
+	System string
+	User   string
+
+	// Time is the fill time. Defaults to the current time.
+	Time time.Time
+
+	// Fields overrides the provenance of single fields by field name,
+	// like values collected earlier by another user.
+	Fields map[string]FieldProvenance
+}
+
+// FieldProvenance records who filled a field.
+type FieldProvenance struct {
+	Field  string    `json:"field"`
+	System string    `json:"system,omitempty"`
+	User   string    `json:"user,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// provenanceRecord is the embedded provenance JSON.
+type provenanceRecord struct {
+	Generated time.Time         `json:"generated"`
+	System    string            `json:"system,omitempty"`
+	User      string            `json:"user,omitempty"`
+	Fields    []FieldProvenance `json:"fields"`
+}
+
+// attachment returns the provenance record of the filled fields as JSON attachment.
+func (p *Provenance) attachment(form Form) (Attachment, error) {
+	now := p.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	rec := provenanceRecord{
+		Generated: now.UTC(),
+		System:    p.System,
+		User:      p.User,
+		Fields:    make([]FieldProvenance, 0, len(form)),
+	}
+
+	for name := range form {
+		fp, ok := p.Fields[name]
+		if !ok {
+			fp = FieldProvenance{System: p.System, User: p.User, Time: now}
+		} else if fp.Time.IsZero() {
+			fp.Time = now
+		}
+		fp.Field = name
+		fp.Time = fp.Time.UTC()
+		rec.Fields = append(rec.Fields, fp)
+	}
+
+	sort.Slice(rec.Fields, func(i, j int) bool {
+		return rec.Fields[i].Field < rec.Fields[j].Field
+	})
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	return Attachment{Name: ProvenanceAttachment, Data: data}, nil
+}
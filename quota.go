@@ -0,0 +1,142 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is matched by the QuotaError of a rejected fill.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError is returned for fills rejected by a TenantLimiter.
+type QuotaError struct {
+	Tenant string
+	Quota  string
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded: tenant '%s': %s", e.Tenant, e.Quota)
+}
+
+// Unwrap returns ErrQuotaExceeded.
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Quota limits the fills of a tenant. Zero values mean no limit.
+type Quota struct {
+	// MaxConcurrent is the maximum number of fills running at once.
+	MaxConcurrent int
+
+	// MaxFillsPerMinute is the maximum number of fills started per minute.
+	MaxFillsPerMinute int
+
+	// MaxBytesPerMinute is the maximum size of the filled PDF files per minute.
+	// Fills are rejected once the limit is reached.
+	MaxBytesPerMinute int64
+}
+
+// TenantLimiter enforces quotas keyed by the Tenant option of the fills.
+// Fills without a tenant are not limited. It is safe for concurrent use.
+type TenantLimiter struct {
+	mutex   sync.Mutex
+	quota   Quota
+	quotas  map[string]Quota
+	tenants map[string]*tenantUsage
+}
+
+type tenantUsage struct {
+	running int
+	window  time.Time
+	fills   int
+	bytes   int64
+}
+
+// NewTenantLimiter creates a new limiter applying the quota to every tenant.
+func NewTenantLimiter(quota Quota) *TenantLimiter {
+	return &TenantLimiter{
+		quota:   quota,
+		quotas:  make(map[string]Quota),
+		tenants: make(map[string]*tenantUsage),
+	}
+}
+
+// SetQuota overrides the quota of the tenant.
+func (l *TenantLimiter) SetQuota(tenant string, quota Quota) {
+	l.mutex.Lock()
+	l.quotas[tenant] = quota
+	l.mutex.Unlock()
+}
+
+// acquire starts a fill of the tenant or returns a QuotaError.
+// It must be followed by a call to release.
+func (l *TenantLimiter) acquire(tenant string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	q, ok := l.quotas[tenant]
+	if !ok {
+		q = l.quota
+	}
+
+	u := l.tenants[tenant]
+	if u == nil {
+		u = &tenantUsage{}
+		l.tenants[tenant] = u
+	}
+
+	now := time.Now()
+	if now.Sub(u.window) >= time.Minute {
+		u.window = now
+		u.fills = 0
+		u.bytes = 0
+	}
+
+	switch {
+	case q.MaxConcurrent > 0 && u.running >= q.MaxConcurrent:
+		return &QuotaError{Tenant: tenant, Quota: fmt.Sprintf("%d concurrent fills", q.MaxConcurrent)}
+	case q.MaxFillsPerMinute > 0 && u.fills >= q.MaxFillsPerMinute:
+		return &QuotaError{Tenant: tenant, Quota: fmt.Sprintf("%d fills per minute", q.MaxFillsPerMinute)}
+	case q.MaxBytesPerMinute > 0 && u.bytes >= q.MaxBytesPerMinute:
+		return &QuotaError{Tenant: tenant, Quota: fmt.Sprintf("%d bytes per minute", q.MaxBytesPerMinute)}
+	}
+
+	u.running++
+	u.fills++
+	return nil
+}
+
+// release finishes a fill of the tenant, which produced n bytes.
+func (l *TenantLimiter) release(tenant string, n int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	u := l.tenants[tenant]
+	u.running--
+	u.bytes += n
+
+	// Drop idle tenants.
+	if u.running == 0 && time.Since(u.window) >= time.Minute {
+		delete(l.tenants, tenant)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// filePath returns the path of the file if the reader is a regular file
+// positioned at its start. Such files are passed by path to pdftk
+// instead of being streamed through stdin.
+func filePath(r io.Reader) (string, bool) {
+	f, ok := r.(*os.File)
+	if !ok {
+		return "", false
+	}
+
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil || pos != 0 {
+		return "", false
+	}
+
+	path, err := filepath.Abs(f.Name())
+	if err != nil {
+		return "", false
+	}
+
+	// Ensure the path still refers to the opened file.
+	pathInfo, err := os.Stat(path)
+	if err != nil || !os.SameFile(info, pathInfo) {
+		return "", false
+	}
+
+	return path, true
+}
+
+// rereadable returns a function creating new readers of the remaining data
+// of the reader, so the data can be read multiple times.
+// io.ReaderAt and io.Seeker implementations, like bytes.Reader,
+// are read without copying the data.
+func rereadable(r io.Reader) (func() io.Reader, error) {
+	ra, ok := r.(io.ReaderAt)
+	s, ok2 := r.(io.Seeker)
+	if !ok || !ok2 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the PDF file: %v", err)
+		}
+		return func() io.Reader {
+			return bytes.NewReader(data)
+		}, nil
+	}
+
+	start, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() io.Reader {
+		return io.NewSectionReader(ra, start, end-start)
+	}, nil
+}
@@ -0,0 +1,310 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TemplateRef identifies a registered template version.
+type TemplateRef struct {
+	Name    string
+	Version string
+}
+
+// String returns the reference in the name@version notation.
+func (r TemplateRef) String() string {
+	if r.Version == "" {
+		return r.Name
+	}
+	return r.Name + "@" + r.Version
+}
+
+// ParseTemplateRef parses a reference in the name@version notation.
+// The version is empty if omitted.
+func ParseTemplateRef(s string) TemplateRef {
+	name, version, _ := strings.Cut(s, "@")
+	return TemplateRef{Name: name, Version: version}
+}
+
+// Registry holds templates by name and version.
+// It is safe for concurrent use.
+type Registry struct {
+	filler    *Filler
+	mutex     sync.RWMutex
+	templates map[string]map[string]*Template
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return DefaultFiller.NewRegistry()
+}
+
+// NewRegistry creates a new empty registry. Templates loaded by the
+// registry are filled by the filler.
+func (f *Filler) NewRegistry() *Registry {
+	return &Registry{
+		filler:    f,
+		templates: make(map[string]map[string]*Template),
+	}
+}
+
+// Register adds the template with the name and version.
+// An already registered template with the same name and version is replaced.
+func (r *Registry) Register(name, version string, t *Template) error {
+	if name == "" || strings.Contains(name, "@") {
+		return fmt.Errorf("invalid template name: '%s'", name)
+	} else if strings.Contains(version, "@") {
+		return fmt.Errorf("invalid template version: '%s'", version)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	versions := r.templates[name]
+	if versions == nil {
+		versions = make(map[string]*Template)
+		r.templates[name] = versions
+	}
+	versions[version] = t
+
+	return nil
+}
+
+// RegisterPinned adds the template with the name and version like Register
+// and pins it to the expected hex encoded SHA-256 checksum of its form
+// PDF file. See Template.Pin.
+func (r *Registry) RegisterPinned(name, version string, t *Template, checksum string) error {
+	err := t.Pin(checksum)
+	if err != nil {
+		return err
+	}
+	return r.Register(name, version, t)
+}
+
+// Unregister removes the template with the name and version.
+func (r *Registry) Unregister(name, version string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.templates[name], version)
+	if len(r.templates[name]) == 0 {
+		delete(r.templates, name)
+	}
+}
+
+// Get returns the template of the reference in the name@version notation,
+// like "w9@v3". The latest version is returned if the version is omitted.
+func (r *Registry) Get(ref string) (*Template, error) {
+	tr := ParseTemplateRef(ref)
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	versions := r.templates[tr.Name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("template does not exist: '%s'", ref)
+	}
+
+	if tr.Version == "" {
+		tr.Version = latestVersion(versions)
+	}
+
+	t, ok := versions[tr.Version]
+	if !ok {
+		return nil, fmt.Errorf("template does not exist: '%s'", ref)
+	}
+	return t, nil
+}
+
+// List returns the references of all registered templates
+// sorted by name and version.
+func (r *Registry) List() []TemplateRef {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var refs []TemplateRef
+	for name, versions := range r.templates {
+		for version := range versions {
+			refs = append(refs, TemplateRef{Name: name, Version: version})
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Name != refs[j].Name {
+			return refs[i].Name < refs[j].Name
+		}
+		return compareVersions(refs[i].Version, refs[j].Version) < 0
+	})
+	return refs
+}
+
+// Fill fills the template of the reference with the specified form values
+// and the default options of the filler of the template.
+func (r *Registry) Fill(ref string, form Form) (result io.Reader, err error) {
+	t, err := r.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	return t.Fill(form)
+}
+
+// FillWithOptions fills the template of the reference with the specified form values and options.
+func (r *Registry) FillWithOptions(ref string, form Form, opts Options) (result io.Reader, err error) {
+	t, err := r.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	return t.FillWithOptions(form, opts)
+}
+
+// LoadDir registers all PDF files of the directory.
+// The files are named by the name@version.pdf notation,
+// like w9@v3.pdf. Files without a version register an empty version.
+func (r *Registry) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		ref, ok := templateFileRef(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		t, err := r.filler.NewTemplate(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+
+		err = r.Register(ref.Name, ref.Version, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadFS registers all PDF files of the directory of the file system.
+// The files are named like for LoadDir and are kept in memory.
+func (r *Registry) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		ref, ok := templateFileRef(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+
+		err = r.Register(ref.Name, ref.Version, r.filler.NewTemplateFromBytes(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadURL downloads the form PDF file from the URL and registers it
+// with the name and version. The template is kept in memory.
+func (r *Registry) LoadURL(ctx context.Context, name, version, url string) error {
+	data, err := downloadTemplate(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	return r.Register(name, version, r.filler.NewTemplateFromBytes(data))
+}
+
+// templateFileRef returns the reference of a template file name.
+func templateFileRef(name string) (TemplateRef, bool) {
+	ext := filepath.Ext(name)
+	if !strings.EqualFold(ext, ".pdf") {
+		return TemplateRef{}, false
+	}
+	return ParseTemplateRef(strings.TrimSuffix(name, ext)), true
+}
+
+// latestVersion returns the highest version of the map.
+func latestVersion(versions map[string]*Template) (latest string) {
+	first := true
+	for v := range versions {
+		if first || compareVersions(v, latest) > 0 {
+			latest = v
+			first = false
+		}
+	}
+	return latest
+}
+
+// compareVersions compares versions like "v3" and "1.10.2" by their
+// numeric parts, so "v10" is newer than "v9".
+func compareVersions(a, b string) int {
+	split := func(v string) []string {
+		v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == '.' || r == '-' || r == '_'
+		})
+	}
+
+	as, bs := split(a), split(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+
+		switch {
+		case aerr == nil && berr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aerr != nil || berr != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
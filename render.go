@@ -0,0 +1,73 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// renderTemplates renders the template form values with the template data
+// of the options. The passed form is not modified.
+func renderTemplates(form Form, opts Options) (Form, error) {
+	var result Form
+
+	for key, value := range form {
+		var (
+			t   *template.Template
+			err error
+		)
+
+		switch v := value.(type) {
+		case *template.Template:
+			t = v
+		case string:
+			if !opts.TemplateStrings || !strings.Contains(v, "{{") {
+				continue
+			}
+			t, err = template.New(key).Option("missingkey=error").Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': invalid template: %v", key, err)
+			}
+		default:
+			continue
+		}
+
+		var b strings.Builder
+		err = t.Execute(&b, opts.TemplateData)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': failed to render template: %v", key, err)
+		}
+
+		// Copy the form on the first rendered value.
+		if result == nil {
+			result = make(Form, len(form))
+			for k, v := range form {
+				result[k] = v
+			}
+		}
+		result[key] = b.String()
+	}
+
+	if result == nil {
+		return form, nil
+	}
+	return result, nil
+}
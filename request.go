@@ -0,0 +1,209 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// FillRequestVersion is the schema version of encoded fill requests.
+// It is increased for incompatible changes of the schema.
+const FillRequestVersion = 1
+
+// FillRequest is a fill which can be encoded as JSON, so it can be
+// enqueued to a message queue and executed by a separate worker with
+// ExecuteFillRequest. Exactly one of Template, URL and Ref references
+// the form PDF file.
+type FillRequest struct {
+	// Version is the schema version. Encode sets it to FillRequestVersion.
+	Version int `json:"version"`
+
+	// Template is the path of the form PDF file on the worker.
+	Template string `json:"template,omitempty"`
+
+	// URL is the HTTP URL the form PDF file is downloaded from.
+	URL string `json:"url,omitempty"`
+
+	// Ref is the reference of a template of the registry of the worker,
+	// like "invoice@2".
+	Ref string `json:"ref,omitempty"`
+
+	Form    Form           `json:"form"`
+	Options RequestOptions `json:"options"`
+}
+
+// RequestOptions are the options of a fill request. They match the
+// Options which can be encoded. Options which can't be encoded, like
+// the Cache, OnFill and ValuePolicy options, are taken from the default
+// options of the worker's Filler.
+type RequestOptions struct {
+	Validate          bool                  `json:"validate,omitempty"`
+	TemplateData      interface{}           `json:"template_data,omitempty"`
+	TemplateStrings   bool                  `json:"template_strings,omitempty"`
+	Flatten           bool                  `json:"flatten,omitempty"`
+	ReplacementFont   string                `json:"replacement_font,omitempty"`
+	ReadOnly          bool                  `json:"read_only,omitempty"`
+	Flags             map[string]FieldFlags `json:"flags,omitempty"`
+	TemplateSHA256    string                `json:"template_sha256,omitempty"`
+	Mapping           Mapping               `json:"mapping,omitempty"`
+	Rules             Rules                 `json:"rules,omitempty"`
+	Expressions       Expressions           `json:"expressions,omitempty"`
+	Appendix          []Section             `json:"appendix,omitempty"`
+	Attachments       []Attachment          `json:"attachments,omitempty"`
+	ViewerPreferences *ViewerPreferences    `json:"viewer_preferences,omitempty"`
+	InitialView       *InitialView          `json:"initial_view,omitempty"`
+	Lang              string                `json:"lang,omitempty"`
+	Stamp             *Stamp                `json:"stamp,omitempty"`
+	EmbedDocumentID   bool                  `json:"embed_document_id,omitempty"`
+	DocumentID        string                `json:"document_id,omitempty"`
+	RequestID         string                `json:"request_id,omitempty"`
+	Signed            SignedPolicy          `json:"signed,omitempty"`
+	Tenant            string                `json:"tenant,omitempty"`
+	Priority          Priority              `json:"priority,omitempty"`
+	SpillLongValues   bool                  `json:"spill_long_values,omitempty"`
+	SpillTitle        string                `json:"spill_title,omitempty"`
+	SpillReference    string                `json:"spill_reference,omitempty"`
+
+	// UserPassword is stored in plain text in the encoded request.
+	// Make sure the queue is encrypted, or encrypt the files after
+	// the fill instead.
+	UserPassword string `json:"user_password,omitempty"`
+
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// Encode encodes the request as JSON with the current schema version.
+func (r FillRequest) Encode() ([]byte, error) {
+	r.Version = FillRequestVersion
+	return json.Marshal(r)
+}
+
+// DecodeFillRequest decodes a JSON fill request. Requests of newer schema
+// versions fail, so workers are updated before the producers.
+// Numbers of the form values are decoded as json.Number to keep them exact.
+func DecodeFillRequest(data []byte) (FillRequest, error) {
+	var r FillRequest
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&r)
+	if err != nil {
+		return r, fmt.Errorf("failed to decode the fill request: %v", err)
+	}
+
+	if r.Version < 1 || r.Version > FillRequestVersion {
+		return r, fmt.Errorf("unsupported fill request version %d", r.Version)
+	}
+	return r, nil
+}
+
+// options returns the options of the request based on the defaults.
+func (o RequestOptions) options(defaults Options) Options {
+	opts := defaults
+	opts.Validate = o.Validate
+	opts.TemplateData = o.TemplateData
+	opts.TemplateStrings = o.TemplateStrings
+	opts.Flatten = o.Flatten
+	opts.ReplacementFont = o.ReplacementFont
+	opts.ReadOnly = o.ReadOnly
+	opts.Flags = o.Flags
+	opts.TemplateSHA256 = o.TemplateSHA256
+	opts.Mapping = o.Mapping
+	opts.Rules = o.Rules
+	opts.Expressions = o.Expressions
+	opts.Appendix = o.Appendix
+	opts.Attachments = o.Attachments
+	opts.ViewerPreferences = o.ViewerPreferences
+	opts.InitialView = o.InitialView
+	opts.Lang = o.Lang
+	opts.Stamp = o.Stamp
+	opts.EmbedDocumentID = o.EmbedDocumentID
+	opts.DocumentID = o.DocumentID
+	opts.RequestID = o.RequestID
+	opts.Signed = o.Signed
+	opts.Tenant = o.Tenant
+	opts.Priority = o.Priority
+	opts.SpillLongValues = o.SpillLongValues
+	opts.SpillTitle = o.SpillTitle
+	opts.SpillReference = o.SpillReference
+	opts.UserPassword = o.UserPassword
+	opts.Permissions = o.Permissions
+	return opts
+}
+
+// ExecuteFillRequest fills the form PDF file of the request and writes
+// the filled PDF file to w. The registry resolves the Ref of requests
+// and may be nil otherwise. The context applies to the download of
+// URL templates.
+func ExecuteFillRequest(ctx context.Context, w io.Writer, r FillRequest, reg *Registry) error {
+	return DefaultFiller.ExecuteFillRequest(ctx, w, r, reg)
+}
+
+// ExecuteFillRequest fills the form PDF file of the request.
+// See the package-level ExecuteFillRequest.
+func (f *Filler) ExecuteFillRequest(ctx context.Context, w io.Writer, r FillRequest, reg *Registry) error {
+	n := 0
+	for _, s := range []string{r.Template, r.URL, r.Ref} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("fill request must reference exactly one template")
+	}
+
+	opts := r.Options.options(f.opts)
+
+	switch {
+	case r.Template != "":
+		path, err := resolveFormFile(r.Template)
+		if err != nil {
+			return err
+		}
+		return f.fillFileTo(w, r.Form, path, opts, func() ([]Field, error) {
+			return f.readFields(path)
+		})
+
+	case r.URL != "":
+		data, err := downloadTemplate(ctx, r.URL)
+		if err != nil {
+			return err
+		}
+		return f.fillTo(w, r.Form, "", bytes.NewReader(data), opts, nil)
+
+	default:
+		if reg == nil {
+			return fmt.Errorf("missing registry for template '%s'", r.Ref)
+		}
+		t, err := reg.Get(r.Ref)
+		if err != nil {
+			return err
+		}
+		result, err := t.FillWithOptions(r.Form, opts)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, result)
+		return err
+	}
+}
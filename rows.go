@@ -0,0 +1,189 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// OverflowPolicy defines how values exceeding the capacity of a template are handled.
+type OverflowPolicy int
+
+const (
+	// OverflowError returns an error.
+	OverflowError OverflowPolicy = iota
+
+	// OverflowTruncate drops the exceeding data.
+	OverflowTruncate
+
+	// OverflowSpill moves the exceeding data to an appendix section,
+	// which is printed on continuation pages.
+	OverflowSpill
+)
+
+// RowMapping maps the rows of a slice onto numbered field families,
+// like Item1Desc, Item1Qty, ..., Item10Desc, Item10Qty.
+type RowMapping struct {
+	// Columns maps the struct field names or map keys of a row to the
+	// field name patterns. A pattern contains %d for the row number,
+	// like "Item%dDesc".
+	Columns map[string]string
+
+	// Rows is the number of rows provided by the template.
+	Rows int
+
+	// Start is the number of the first row. Defaults to 1.
+	Start int
+
+	// Overflow defines how rows exceeding the template rows are handled.
+	Overflow OverflowPolicy
+
+	// Title is the title of the appendix section of spilled rows.
+	Title string
+}
+
+// Map sets the form values of the rows. The rows must be a slice of
+// structs, struct pointers or maps with string keys.
+// A section is returned if rows have been spilled with the OverflowSpill
+// policy. Pass it with the Appendix option to append it to the filled PDF file.
+// Rows with missing columns fail. The form is only modified on success.
+func (m RowMapping) Map(form Form, rows interface{}) (spill *Section, err error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("rows must be a slice: %T", rows)
+	}
+
+	start := m.Start
+	if start == 0 {
+		start = 1
+	}
+
+	n := v.Len()
+	if n > m.Rows {
+		switch m.Overflow {
+		case OverflowError:
+			return nil, fmt.Errorf("too many rows: %d > %d", n, m.Rows)
+		case OverflowSpill:
+			spill, err = m.spill(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		n = m.Rows
+	}
+
+	columns := m.columns()
+	mapped := make(Form, n*len(columns))
+	for i := 0; i < n; i++ {
+		values, err := rowValues(v.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i, err)
+		}
+
+		for _, column := range columns {
+			value, ok := values[column]
+			if !ok {
+				return nil, fmt.Errorf("row %d: missing column '%s'", i, column)
+			}
+			mapped[fmt.Sprintf(m.Columns[column], start+i)] = value
+		}
+	}
+
+	for k, value := range mapped {
+		form[k] = value
+	}
+	return spill, nil
+}
+
+// columns returns the sorted column names.
+func (m RowMapping) columns() []string {
+	columns := make([]string, 0, len(m.Columns))
+	for c := range m.Columns {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// spill returns the appendix section of the rows exceeding the template rows.
+func (m RowMapping) spill(v reflect.Value) (*Section, error) {
+	columns := m.columns()
+	s := &Section{
+		Title: m.Title,
+		Lines: []string{strings.Join(columns, " | ")},
+	}
+
+	for i := m.Rows; i < v.Len(); i++ {
+		values, err := rowValues(v.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i, err)
+		}
+
+		cells := make([]string, len(columns))
+		for j, c := range columns {
+			value, ok := values[c]
+			if !ok {
+				return nil, fmt.Errorf("row %d: missing column '%s'", i, c)
+			}
+			cells[j] = formatValue(value)
+		}
+		s.Lines = append(s.Lines, strings.Join(cells, " | "))
+	}
+
+	return s, nil
+}
+
+// rowValues returns the values of a struct or map row by name.
+func rowValues(v reflect.Value) (map[string]interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("row is nil")
+		}
+		v = v.Elem()
+	}
+
+	values := make(map[string]interface{})
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				values[t.Field(i).Name] = v.Field(i).Interface()
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map keys must be strings")
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = iter.Value().Interface()
+		}
+
+	default:
+		return nil, fmt.Errorf("row must be a struct or map: %s", v.Type())
+	}
+
+	return values, nil
+}
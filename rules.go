@@ -0,0 +1,109 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+)
+
+// Condition operators.
+const (
+	OpEqual    = "eq"
+	OpNotEqual = "ne"
+	OpEmpty    = "empty"
+	OpNotEmpty = "notEmpty"
+)
+
+// Condition compares a form value.
+// Values are compared by their string representation, so
+// the condition value true matches the form values true and "Yes".
+type Condition struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op,omitempty"` // Defaults to OpEqual.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Rule sets form values if all of its conditions are met.
+type Rule struct {
+	If  []Condition `json:"if"`
+	Set Form        `json:"set"`
+}
+
+// Rules is an ordered list of rules.
+// Rules are declared as data and can be decoded from JSON:
+//
+//	[{"if": [{"field": "Married", "value": true}], "set": {"SpouseSection": true}}]
+type Rules []Rule
+
+// Apply evaluates the rules in order and returns a new form with the derived values.
+// Later rules see the values set by earlier rules. The passed form is not modified.
+func (rs Rules) Apply(form Form) (Form, error) {
+	result := make(Form, len(form))
+	for k, v := range form {
+		result[k] = v
+	}
+
+	for i, r := range rs {
+		ok, err := r.match(result)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		} else if !ok {
+			continue
+		}
+
+		for k, v := range r.Set {
+			result[k] = v
+		}
+	}
+
+	return result, nil
+}
+
+// match returns whether all conditions of the rule are met.
+func (r Rule) match(form Form) (bool, error) {
+	for _, c := range r.If {
+		ok, err := c.match(form)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// match returns whether the condition is met.
+func (c Condition) match(form Form) (bool, error) {
+	var value string
+	v, ok := form[c.Field]
+	if ok && v != nil {
+		value = formatValue(v)
+	}
+
+	switch c.Op {
+	case OpEqual, "":
+		return ok && value == formatValue(c.Value), nil
+	case OpNotEqual:
+		return !ok || value != formatValue(c.Value), nil
+	case OpEmpty:
+		return value == "" || value == "Off", nil
+	case OpNotEmpty:
+		return value != "" && value != "Off", nil
+	default:
+		return false, fmt.Errorf("invalid condition operator: '%s'", c.Op)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	openAPIVersion  = "3.1.0"
+)
+
+// JSONSchema returns a JSON Schema describing the form fields of the template.
+// Push buttons and signature fields are not part of the schema.
+func (t *Template) JSONSchema() ([]byte, error) {
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	schema := fieldsSchema(fields)
+	schema["$schema"] = jsonSchemaDraft
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// OpenAPI returns an OpenAPI document describing a fill endpoint for the template
+// at the specified path. The endpoint accepts the form values as JSON object
+// and responds with the filled PDF file.
+func (t *Template) OpenAPI(path string) ([]byte, error) {
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(t.path), filepath.Ext(t.path))
+
+	doc := map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   name,
+			"version": "1.0.0",
+		},
+		"paths": map[string]interface{}{
+			path: map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary": "Fill the " + name + " PDF form.",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"$ref": "#/components/schemas/Form",
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "The filled PDF file.",
+							"content": map[string]interface{}{
+								"application/pdf": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type":   "string",
+										"format": "binary",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Form": fieldsSchema(fields),
+			},
+		},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// fieldsSchema returns the JSON Schema object describing the fields.
+func fieldsSchema(fields []Field) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for _, f := range fields {
+		s := fieldSchema(f)
+		if s == nil {
+			continue
+		}
+		properties[f.Name] = s
+
+		if f.IsRequired() {
+			required = append(required, f.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// fieldSchema returns the JSON Schema of a single field or nil
+// if the field can not be filled.
+func fieldSchema(f Field) map[string]interface{} {
+	var s map[string]interface{}
+
+	switch {
+	case f.IsPushbutton(), f.Type == FieldTypeSignature:
+		return nil
+
+	case f.IsCheckbox() && isDefaultCheckbox(f):
+		s = map[string]interface{}{
+			"type": "boolean",
+		}
+
+	case f.Type == FieldTypeButton && len(f.Options) > 0,
+		f.Type == FieldTypeChoice && f.Flags&FieldFlagEdit == 0 && len(f.Options) > 0:
+		s = map[string]interface{}{
+			"type": "string",
+			"enum": f.Options,
+		}
+
+	default:
+		s = map[string]interface{}{
+			"type": "string",
+		}
+		if f.MaxLength > 0 {
+			s["maxLength"] = f.MaxLength
+		}
+	}
+
+	if f.AltName != "" {
+		s["description"] = f.AltName
+	}
+	if f.IsReadOnly() {
+		s["readOnly"] = true
+	}
+
+	return s
+}
+
+// isDefaultCheckbox returns whether the check box uses the Yes/Off states
+// which are set by boolean form values.
+func isDefaultCheckbox(f Field) bool {
+	for _, o := range f.Options {
+		if o != "Yes" && o != "Off" {
+			return false
+		}
+	}
+	return true
+}
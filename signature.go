@@ -0,0 +1,90 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrAlreadySigned is returned for fills of digitally signed PDF files
+// with the SignedError policy.
+var ErrAlreadySigned = errors.New("PDF file is digitally signed")
+
+// SignedPolicy defines how fills handle digitally signed PDF files.
+// pdftk rewrites the whole file, which invalidates existing signatures.
+type SignedPolicy int
+
+const (
+	// SignedIgnore fills signed PDF files without checking for signatures.
+	SignedIgnore SignedPolicy = iota
+
+	// SignedError fails fills of signed PDF files with ErrAlreadySigned.
+	SignedError
+
+	// SignedUnchanged returns signed PDF files unchanged instead of
+	// filling them, so the signatures stay valid.
+	SignedUnchanged
+
+	// SignedProceed fills signed PDF files and logs a warning to the
+	// logger of the Filler.
+	SignedProceed
+)
+
+// IsSigned returns whether the PDF file contains a digital signature.
+func IsSigned(pdfFile io.Reader) (bool, error) {
+	data, err := io.ReadAll(pdfFile)
+	if err != nil {
+		return false, err
+	}
+	return isSigned(data), nil
+}
+
+// isSigned returns whether the PDF data contains a signature dictionary,
+// which is identified by its required ByteRange entry.
+func isSigned(data []byte) bool {
+	return newScanner(data).hasName("ByteRange")
+}
+
+// checkSigned applies the SignedPolicy of the options to the PDF data.
+// It returns whether the PDF file has to be returned unchanged.
+func (f *Filler) checkSigned(data []byte, template string, opts Options) (unchanged bool, err error) {
+	if opts.Signed == SignedIgnore || !isSigned(data) {
+		return false, nil
+	}
+
+	if template == "" {
+		template = "PDF file"
+	}
+
+	switch opts.Signed {
+	case SignedError:
+		return false, ErrAlreadySigned
+	case SignedUnchanged:
+		if f.logger != nil {
+			f.logger.Printf("%s is digitally signed: returned unchanged", template)
+		}
+		return true, nil
+	default:
+		if f.logger != nil {
+			f.logger.Printf("%s is digitally signed: the fill invalidates the signatures", template)
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"sort"
+)
+
+const (
+	defaultSpillTitle     = "Attachment A"
+	defaultSpillReference = "See attachment A"
+
+	// spillMarker is filled into fields shorter than the reference.
+	spillMarker = "*"
+)
+
+// spillLongValues moves the values exceeding the maximum length of their
+// text fields to an appendix section. Comb fields are skipped, because
+// the reference would not fit their boxes. The passed form is not modified.
+func spillLongValues(form Form, fields []Field, opts Options) (Form, *Section) {
+	title, ref := opts.SpillTitle, opts.SpillReference
+	if title == "" {
+		title = defaultSpillTitle
+	}
+	if ref == "" {
+		ref = defaultSpillReference
+	}
+
+	var long []Field
+	for _, f := range fields {
+		v, ok := form[f.Name]
+		if !ok || f.Type != FieldTypeText || f.MaxLength <= 0 || f.IsComb() {
+			continue
+		}
+		if len([]rune(formatValue(v))) > f.MaxLength {
+			long = append(long, f)
+		}
+	}
+	if len(long) == 0 {
+		return form, nil
+	}
+
+	sort.Slice(long, func(i, j int) bool {
+		return long[i].Name < long[j].Name
+	})
+
+	result := make(Form, len(form))
+	for k, v := range form {
+		result[k] = v
+	}
+
+	s := &Section{Title: title}
+	for _, f := range long {
+		name := f.AltName
+		if name == "" {
+			name = f.Name
+		}
+		s.Lines = append(s.Lines, name+": "+formatValue(form[f.Name]))
+
+		if len([]rune(ref)) > f.MaxLength {
+			result[f.Name] = spillMarker
+		} else {
+			result[f.Name] = ref
+		}
+	}
+
+	return result, s
+}
@@ -0,0 +1,105 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"database/sql"
+)
+
+// FormFromRow creates a form from the current row of the rows.
+// The mapping maps column names to field names. Columns without a mapping
+// are skipped. The column names are used as field names if the mapping is nil.
+// NULL values are filled as empty text and byte slices as text.
+func FormFromRow(rows *sql.Rows, mapping map[string]string) (Form, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	err = rows.Scan(ptrs...)
+	if err != nil {
+		return nil, err
+	}
+
+	form := make(Form, len(columns))
+	for i, c := range columns {
+		name := c
+		if mapping != nil {
+			var ok bool
+			name, ok = mapping[c]
+			if !ok {
+				continue
+			}
+		}
+
+		switch v := values[i].(type) {
+		case nil:
+			form[name] = ""
+		case []byte:
+			form[name] = string(v)
+		default:
+			form[name] = v
+		}
+	}
+	return form, nil
+}
+
+// FormsFromRows creates a form from every remaining row of the rows.
+// See FormFromRow for the mapping. The rows are closed.
+func FormsFromRows(rows *sql.Rows, mapping map[string]string) ([]Form, error) {
+	defer rows.Close()
+
+	var forms []Form
+	for rows.Next() {
+		form, err := FormFromRow(rows, mapping)
+		if err != nil {
+			return nil, err
+		}
+		forms = append(forms, form)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return forms, nil
+}
+
+// FillRows fills the PDF form once for every remaining row of the rows.
+// See FormFromRow for the mapping. Set the Merge option to create a
+// single PDF file of all rows.
+func FillRows(rows *sql.Rows, mapping map[string]string, formPDFFile string, opts BatchOptions) (*BatchResult, error) {
+	return DefaultFiller.FillRows(rows, mapping, formPDFFile, opts)
+}
+
+// FillRows fills the PDF form once for every remaining row of the rows.
+// See the package-level FillRows.
+func (f *Filler) FillRows(rows *sql.Rows, mapping map[string]string, formPDFFile string, opts BatchOptions) (*BatchResult, error) {
+	forms, err := FormsFromRows(rows, mapping)
+	if err != nil {
+		return nil, err
+	}
+	return f.FillBatchWithOptions(forms, formPDFFile, opts)
+}
@@ -0,0 +1,112 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultStampText is the footer text of a Stamp without a text.
+const DefaultStampText = `Generated {{.Time.Format "2006-01-02 15:04:05 MST"}}{{if .User}} by {{.User}}{{end}}{{if .DocumentID}} | {{.DocumentID}}{{end}} | {{.Hash}}`
+
+// Stamp is a footer printed on every page of the filled PDF file,
+// so generated documents can be traced.
+type Stamp struct {
+	// Text is a text/template rendered with the StampData.
+	// Defaults to DefaultStampText.
+	Text string
+
+	// User is the user generating the document.
+	User string
+
+	// DocumentID identifies the document. Defaults to the RequestID option.
+	DocumentID string
+}
+
+// StampData is the data of the stamp text template.
+type StampData struct {
+	Time       time.Time
+	User       string
+	DocumentID string
+
+	// Hash is the SHA-256 hash of the filled form values.
+	Hash string
+}
+
+// render renders the stamp text for the filled form.
+func (s Stamp) render(form Form, opts Options) (string, error) {
+	text := s.Text
+	if text == "" {
+		text = DefaultStampText
+	}
+	t, err := template.New("stamp").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid stamp template: %v", err)
+	}
+
+	data := StampData{
+		Time:       time.Now(),
+		User:       s.User,
+		DocumentID: s.DocumentID,
+		Hash:       formHash(form),
+	}
+	if data.DocumentID == "" {
+		data.DocumentID = opts.RequestID
+	}
+
+	var b strings.Builder
+	err = t.Execute(&b, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the stamp: %v", err)
+	}
+	return b.String(), nil
+}
+
+// formHash returns the SHA-256 hash of the sorted form values.
+func formHash(form Form) string {
+	h := sha256.New()
+	for _, k := range form.Keys() {
+		fmt.Fprintf(h, "%q=%q\n", k, formatValue(form[k]))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// stampPages stamps the text at the bottom of every page of the PDF file.
+func (f *Filler) stampPages(p Priority, pdfFile []byte, text string) ([]byte, error) {
+	tmp, err := os.CreateTemp(f.tempDir, "fillpdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = tmp.Write(renderStampPDF(text))
+	if err != nil {
+		return nil, err
+	}
+
+	return f.output(p, bytes.NewReader(pdfFile), "-", "stamp", tmp.Name(), "output", "-")
+}
@@ -0,0 +1,130 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobGetter reads blobs from a storage, like S3 or GCS buckets.
+type BlobGetter interface {
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// BlobPutter writes blobs to a storage, like S3 or GCS buckets.
+type BlobPutter interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+}
+
+// FillFromStorage reads the form PDF file with the key from the getter,
+// fills it with the specified form values and writes the filled PDF file
+// with the output key to the putter. The form PDF file is streamed to pdftk,
+// but the FDF data and the files of options like attachments, stamps or
+// encryption are written to temporary files in the TempDir of the filler,
+// which are removed after the fill.
+func FillFromStorage(ctx context.Context, getter BlobGetter, key string, form Form, putter BlobPutter, outKey string) error {
+	return DefaultFiller.FillFromStorage(ctx, getter, key, form, putter, outKey)
+}
+
+// FillFromStorageWithOptions reads the form PDF file with the key from the getter,
+// fills it with the specified form values and options and writes the filled
+// PDF file with the output key to the putter. Temporary files are written
+// like by FillFromStorage.
+func FillFromStorageWithOptions(ctx context.Context, getter BlobGetter, key string, form Form, putter BlobPutter, outKey string, opts Options) error {
+	return DefaultFiller.FillFromStorageWithOptions(ctx, getter, key, form, putter, outKey, opts)
+}
+
+// FillFromStorage fills the form PDF file of the getter with the default
+// options of the filler. See the package-level FillFromStorage.
+func (f *Filler) FillFromStorage(ctx context.Context, getter BlobGetter, key string, form Form, putter BlobPutter, outKey string) error {
+	return f.FillFromStorageWithOptions(ctx, getter, key, form, putter, outKey, f.opts)
+}
+
+// FillFromStorageWithOptions fills the form PDF file of the getter with the
+// options. See the package-level FillFromStorageWithOptions.
+func (f *Filler) FillFromStorageWithOptions(ctx context.Context, getter BlobGetter, key string, form Form, putter BlobPutter, outKey string, opts Options) error {
+	rc, err := getter.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to get the form PDF file '%s': %v", key, err)
+	}
+	defer rc.Close()
+
+	result, err := f.FillFromReaderWithOptions(form, rc, opts)
+	if err != nil {
+		return err
+	}
+
+	err = putter.Put(ctx, outKey, result)
+	if err != nil {
+		return fmt.Errorf("failed to put the filled PDF file '%s': %v", outKey, err)
+	}
+
+	return nil
+}
+
+// DirStorage is a BlobGetter and BlobPutter storing the blobs as files
+// in a directory. The keys are slash separated paths relative to the directory.
+type DirStorage string
+
+// Get opens the file of the key.
+func (d DirStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Put writes the file of the key.
+func (d DirStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// path returns the file path of the key.
+func (d DirStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid key: '%s'", key)
+	}
+	return filepath.Join(string(d), filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,95 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"io"
+	"net/http"
+)
+
+// FillErrorTrailer is the HTTP trailer signaling an error which
+// occurred after the response body has been started.
+const FillErrorTrailer = "Fill-Error"
+
+// FillTo fills a PDF form with the specified form values and options
+// and writes the filled PDF file to w. The output of pdftk is streamed
+// to w as it is produced, so the filled PDF file is not buffered in memory.
+// Options requiring post-processing, like the Appendix option,
+// buffer the filled PDF file.
+func FillTo(w io.Writer, form Form, formPDFFile string, opts Options) (err error) {
+	return DefaultFiller.FillTo(w, form, formPDFFile, opts)
+}
+
+// FillTo fills a PDF form with the specified form values and options
+// and writes the filled PDF file to w. See the package-level FillTo.
+func (f *Filler) FillTo(w io.Writer, form Form, formPDFFile string, opts Options) (err error) {
+	if !opts.Fast {
+		formPDFFile, err = resolveFormFile(formPDFFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.fillFileTo(w, form, formPDFFile, opts, func() ([]Field, error) {
+		return f.readFields(formPDFFile)
+	})
+}
+
+// ServeFill fills a PDF form and streams the filled PDF file as HTTP response.
+// Errors occurring before the first byte is written result in an internal
+// server error response. Later errors can't change the response status
+// anymore and are signaled with the FillErrorTrailer HTTP trailer instead.
+// Clients must check the trailer after reading the body.
+func ServeFill(w http.ResponseWriter, form Form, formPDFFile string, opts Options) error {
+	return DefaultFiller.ServeFill(w, form, formPDFFile, opts)
+}
+
+// ServeFill fills a PDF form and streams the filled PDF file as HTTP response.
+// See the package-level ServeFill.
+func (f *Filler) ServeFill(w http.ResponseWriter, form Form, formPDFFile string, opts Options) error {
+	w.Header().Set("Trailer", FillErrorTrailer)
+	w.Header().Set("Content-Type", "application/pdf")
+
+	cw := &countingWriter{w: w}
+	err := f.FillTo(cw, form, formPDFFile, opts)
+	if err == nil {
+		return nil
+	}
+
+	if cw.n == 0 {
+		w.Header().Del("Trailer")
+		http.Error(w, "failed to fill the PDF form", http.StatusInternalServerError)
+	} else {
+		w.Header().Set(FillErrorTrailer, err.Error())
+	}
+
+	return err
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,135 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minSuggestionScore is the minimum similarity of fuzzy matches.
+const minSuggestionScore = 0.6
+
+// MappingSuggestion is a suggested mapping of a data key to a field.
+type MappingSuggestion struct {
+	Key   string
+	Field string
+
+	// Score is the similarity of the names from 0 to 1.
+	// Exact matches ignoring case and punctuation score 1.
+	Score float64
+}
+
+// SuggestMapping suggests field names for the keys of sample data, like
+// CSV headers or JSON keys, by exact and fuzzy matches of the names.
+// Every field is suggested for at most one key, and keys without a
+// similar field are skipped. The suggestions are sorted by the key.
+// See MappingFromSuggestions to use them as mapping.
+func SuggestMapping(keys []string, fields []Field) []MappingSuggestion {
+	var candidates []MappingSuggestion
+	for _, k := range keys {
+		nk := normalizeName(k)
+		for _, f := range fields {
+			if f.IsPushbutton() {
+				continue
+			}
+			score := nameSimilarity(nk, normalizeName(f.Name))
+			if score >= minSuggestionScore {
+				candidates = append(candidates, MappingSuggestion{Key: k, Field: f.Name, Score: score})
+			}
+		}
+	}
+
+	// The best matches are assigned first.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	var (
+		suggestions []MappingSuggestion
+		usedKeys    = make(map[string]bool)
+		usedFields  = make(map[string]bool)
+	)
+	for _, c := range candidates {
+		if usedKeys[c.Key] || usedFields[c.Field] {
+			continue
+		}
+		usedKeys[c.Key] = true
+		usedFields[c.Field] = true
+		suggestions = append(suggestions, c)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Key < suggestions[j].Key
+	})
+	return suggestions
+}
+
+// MappingFromSuggestions returns the suggestions as mapping of keys to
+// field names, like the mapping of FormFromRow.
+func MappingFromSuggestions(suggestions []MappingSuggestion) map[string]string {
+	mapping := make(map[string]string, len(suggestions))
+	for _, s := range suggestions {
+		mapping[s.Key] = s.Field
+	}
+	return mapping
+}
+
+// normalizeName returns the lower case letters and digits of the name,
+// so "First Name", "first_name" and "FirstName" are equal.
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// nameSimilarity returns the similarity of the normalized names
+// based on their Levenshtein distance.
+func nameSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
@@ -0,0 +1,222 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrTemplateModified is returned for fills of templates which don't
+// match their pinned checksum.
+var ErrTemplateModified = errors.New("template has been modified")
+
+// Template is a form PDF file which is filled multiple times.
+// The absolute path and the file metadata are resolved only once
+// and resolved again after a failed fill.
+// Templates created from data are kept in memory.
+type Template struct {
+	filler *Filler
+	path   string
+	data   []byte
+
+	mutex    sync.Mutex
+	info     os.FileInfo
+	fields   []Field
+	checksum string
+}
+
+// NewTemplate creates a new template from the form PDF file.
+func NewTemplate(formPDFFile string) (*Template, error) {
+	return DefaultFiller.NewTemplate(formPDFFile)
+}
+
+// NewTemplateFromBytes creates a new template from the data of a form PDF file.
+// The data must not be modified afterwards.
+func NewTemplateFromBytes(data []byte) *Template {
+	return DefaultFiller.NewTemplateFromBytes(data)
+}
+
+// NewTemplate creates a new template from the form PDF file,
+// which is filled by the filler.
+func (f *Filler) NewTemplate(formPDFFile string) (*Template, error) {
+	// Get the absolute paths.
+	path, err := filepath.Abs(formPDFFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the absolute path: %v", err)
+	}
+
+	t := &Template{
+		filler: f,
+		path:   path,
+	}
+
+	_, err = t.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// NewTemplateFromBytes creates a new template from the data of a form PDF file,
+// which is filled by the filler. The data must not be modified afterwards.
+func (f *Filler) NewTemplateFromBytes(data []byte) *Template {
+	return &Template{
+		filler: f,
+		data:   data,
+	}
+}
+
+// Path returns the absolute path of the form PDF file.
+// The path is empty for templates created from data.
+func (t *Template) Path() string {
+	return t.path
+}
+
+// Stat returns the cached file metadata of the form PDF file.
+// The metadata is nil for templates created from data.
+func (t *Template) Stat() (os.FileInfo, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.info != nil || t.data != nil {
+		return t.info, nil
+	}
+
+	info, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("form PDF file does not exist: '%s'", t.path)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to check if form PDF file exists: %v", err)
+	}
+
+	t.info = info
+	return info, nil
+}
+
+// Fields returns the form fields of the template.
+// The fields are read only once.
+func (t *Template) Fields() ([]Field, error) {
+	t.mutex.Lock()
+	fields := t.fields
+	t.mutex.Unlock()
+
+	if fields != nil {
+		return fields, nil
+	}
+
+	var err error
+	if t.data != nil {
+		fields, err = t.filler.ReadFieldsFromReader(bytes.NewReader(t.data))
+	} else {
+		fields, err = t.filler.readFields(t.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	t.fields = fields
+	t.mutex.Unlock()
+
+	return fields, nil
+}
+
+// Fill fills the template with the specified form values and the default
+// options of its filler and creates a final filled PDF file.
+func (t *Template) Fill(form Form) (result io.Reader, err error) {
+	return t.FillWithOptions(form, t.filler.opts)
+}
+
+// Pin sets the expected hex encoded SHA-256 checksum of the form PDF file,
+// which protects against templates swapped in shared storage. The file is
+// verified now and before every fill, which fails with ErrTemplateModified
+// if the file does not match. The TemplateSHA256 option takes precedence.
+func (t *Template) Pin(checksum string) error {
+	data, err := t.readData()
+	if err != nil {
+		return err
+	}
+
+	err = verifyChecksum(data, checksum)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.checksum = checksum
+	t.mutex.Unlock()
+	return nil
+}
+
+// FillWithOptions fills the template with the specified form values and options
+// and creates a final filled PDF file.
+func (t *Template) FillWithOptions(form Form, opts Options) (result io.Reader, err error) {
+	return t.fillWith(t.filler, form, opts)
+}
+
+// fillWith fills the template with the pdftk command, limits and
+// hooks of the filler instead of the filler of the template.
+// The fields are still read and cached by the template.
+func (t *Template) fillWith(f *Filler, form Form, opts Options) (result io.Reader, err error) {
+	_, err = t.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.TemplateSHA256 == "" {
+		t.mutex.Lock()
+		opts.TemplateSHA256 = t.checksum
+		t.mutex.Unlock()
+	}
+
+	if t.data != nil {
+		return f.fillReader(form, bytes.NewReader(t.data), opts, t.Fields)
+	}
+
+	result, err = f.fillFile(form, t.path, opts, t.Fields)
+	if err != nil {
+		t.invalidate()
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// invalidate drops the cached file metadata and fields.
+func (t *Template) invalidate() {
+	t.mutex.Lock()
+	t.info = nil
+	t.fields = nil
+	t.mutex.Unlock()
+}
+
+// readData returns the data of the form PDF file.
+func (t *Template) readData() ([]byte, error) {
+	if t.data != nil {
+		return t.data, nil
+	}
+	return os.ReadFile(t.path)
+}
@@ -0,0 +1,219 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Section is a titled block of text printed on pages
+// appended to the filled PDF file.
+type Section struct {
+	Title string
+	Lines []string
+}
+
+// Page layout of the generated text pages (US Letter, points).
+const (
+	textPageWidth    = 612
+	textPageHeight   = 792
+	textPageMargin   = 72
+	textFontSize     = 10
+	textTitleSize    = 12
+	textLineHeight   = 14
+	textLineMaxChars = 90
+
+	// The stamp footer is placed within the bottom margin.
+	textStampSize = 7
+	textStampY    = 24
+)
+
+// renderTextPDF renders the sections to a simple PDF document using the
+// standard Helvetica font. Characters outside of Latin-1 are transliterated
+// or replaced.
+func renderTextPDF(sections []Section) []byte {
+	pages := layoutTextPages(sections)
+
+	// Objects: 1 catalog, 2 pages, 3 and 4 fonts, then page and content pairs.
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, p := range pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+				"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				textPageWidth, textPageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(p), p),
+		)
+	}
+
+	return writePDFObjects(objects)
+}
+
+// renderStampPDF renders a single page with the text as footer.
+func renderStampPDF(text string) []byte {
+	content := fmt.Sprintf("BT /F1 %d Tf %d %d Td (%s) Tj ET", textStampSize, textPageMargin, textStampY, escapeTextPDF(text))
+	return writePDFObjects([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents 5 0 R >>",
+			textPageWidth, textPageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	})
+}
+
+// layoutTextPages returns the content streams of the pages of the sections.
+func layoutTextPages(sections []Section) []string {
+	var (
+		pages   []string
+		content strings.Builder
+		y       = textPageHeight - textPageMargin
+	)
+
+	newPage := func() {
+		pages = append(pages, content.String())
+		content.Reset()
+		y = textPageHeight - textPageMargin
+	}
+	writeLine := func(font string, size int, s string) {
+		if y < textPageMargin {
+			newPage()
+		}
+		fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n",
+			font, size, textPageMargin, y, escapeTextPDF(s))
+		y -= textLineHeight
+	}
+
+	for i, s := range sections {
+		if i > 0 {
+			y -= textLineHeight
+		}
+		if s.Title != "" {
+			writeLine("F2", textTitleSize, s.Title)
+			y -= textLineHeight / 2
+		}
+		for _, l := range s.Lines {
+			for _, wl := range wrapText(l, textLineMaxChars) {
+				writeLine("F1", textFontSize, wl)
+			}
+		}
+	}
+	newPage()
+
+	return pages
+}
+
+// writePDFObjects writes a PDF document with the objects numbered from one.
+// The first object must be the catalog.
+func writePDFObjects(objects []string) []byte {
+	w := bytes.NewBuffer(nil)
+	w.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = w.Len()
+		fmt.Fprintf(w, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+
+	xref := w.Len()
+	fmt.Fprintf(w, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(w, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(w, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return w.Bytes()
+}
+
+// escapeTextPDF encodes the text as WinAnsi PDF string content.
+func escapeTextPDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			if t, ok := transliterate(r); ok {
+				b.WriteString(escapeTextPDF(t))
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+// wrapText splits the text into lines of at most max characters.
+// Lines are broken at spaces if possible.
+func wrapText(s string, max int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		r := []rune(para)
+		for len(r) > max {
+			cut := max
+			for i := max; i > max/2; i-- {
+				if r[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			lines = append(lines, strings.TrimRight(string(r[:cut]), " "))
+			r = []rune(strings.TrimLeft(string(r[cut:]), " "))
+		}
+		lines = append(lines, string(r))
+	}
+	return lines
+}
+
+// appendPages appends the pages of the extra PDF file to the PDF file.
+func (f *Filler) appendPages(p Priority, pdfFile []byte, extra []byte) ([]byte, error) {
+	tmp, err := os.CreateTemp(f.tempDir, "fillpdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = tmp.Write(extra)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.output(p, bytes.NewReader(pdfFile), "A=-", "B="+tmp.Name(), "cat", "A", "B", "output", "-")
+}
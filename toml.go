@@ -0,0 +1,545 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	tomlIntRegexp      = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	tomlPrefixedRegexp = regexp.MustCompile(`^0(x[0-9A-Fa-f](_?[0-9A-Fa-f])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+	tomlFloatRegexp    = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+	tomlDateTimeRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?$`)
+	tomlTimeRegexp     = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+)
+
+// decodeTOML decodes a TOML document into maps, slices, strings,
+// json.Number numbers and booleans, like a JSON document decoded with
+// UseNumber. Dates and times are kept as strings. Infinite and NaN
+// floats are not supported.
+func decodeTOML(data []byte) (map[string]interface{}, error) {
+	p := &tomlParser{
+		s:       strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n"),
+		root:    make(map[string]interface{}),
+		defined: make(map[string]bool),
+		arrays:  make(map[string]bool),
+	}
+	err := p.document()
+	if err != nil {
+		return nil, err
+	}
+	return p.root, nil
+}
+
+// tomlParser parses a TOML document.
+type tomlParser struct {
+	s   string
+	pos int
+
+	root    map[string]interface{}
+	current map[string]interface{}
+
+	// defined contains the paths of the tables defined by headers and
+	// arrays the paths of the arrays of tables.
+	defined map[string]bool
+	arrays  map[string]bool
+}
+
+// errorf returns an error at the current line.
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.s[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// peek returns the current byte or 0 at the end.
+func (p *tomlParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment up to the end of the line.
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips spaces, comments and line breaks.
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		if p.peek() != '\n' {
+			return
+		}
+		p.pos++
+	}
+}
+
+// endOfLine expects the end of the line after a value or header.
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	p.skipComment()
+	switch p.peek() {
+	case 0:
+		return nil
+	case '\n':
+		p.pos++
+		return nil
+	}
+	return p.errorf("expected the end of the line, got '%c'", p.peek())
+}
+
+func (p *tomlParser) document() error {
+	p.current = p.root
+	for {
+		p.skipBlank()
+		if p.pos == len(p.s) {
+			return nil
+		}
+
+		var err error
+		if strings.HasPrefix(p.s[p.pos:], "[[") {
+			err = p.arrayTable()
+		} else if p.peek() == '[' {
+			err = p.table()
+		} else {
+			err = p.keyValue(p.current)
+		}
+		if err != nil {
+			return err
+		}
+		if err = p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// table parses a table header like [a.b].
+func (p *tomlParser) table() error {
+	p.pos++
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	if p.peek() != ']' {
+		return p.errorf("expected ']' after the table name")
+	}
+	p.pos++
+
+	path := strings.Join(keys, "\x00")
+	if p.defined[path] || p.arrays[path] {
+		return p.errorf("table '%s' is already defined", strings.Join(keys, "."))
+	}
+	p.defined[path] = true
+
+	p.current, err = p.subTable(p.root, keys)
+	return err
+}
+
+// arrayTable parses an array of tables header like [[a.b]].
+func (p *tomlParser) arrayTable() error {
+	p.pos += 2
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(p.s[p.pos:], "]]") {
+		return p.errorf("expected ']]' after the array of tables name")
+	}
+	p.pos += 2
+
+	path := strings.Join(keys, "\x00")
+	parent, err := p.subTable(p.root, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	arr, ok := parent[last].([]interface{})
+	if _, exists := parent[last]; exists && (!ok || !p.arrays[path]) {
+		return p.errorf("key '%s' is not an array of tables", strings.Join(keys, "."))
+	}
+	p.arrays[path] = true
+
+	// The tables of the previous element may be defined again.
+	for k := range p.defined {
+		if strings.HasPrefix(k, path+"\x00") {
+			delete(p.defined, k)
+		}
+	}
+
+	p.current = make(map[string]interface{})
+	parent[last] = append(arr, p.current)
+	return nil
+}
+
+// subTable returns the nested table of the keys, which is created if it
+// does not exist. The last table of arrays of tables is used.
+func (p *tomlParser) subTable(m map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for i, k := range keys {
+		switch v := m[k].(type) {
+		case nil:
+			t := make(map[string]interface{})
+			m[k] = t
+			m = t
+		case map[string]interface{}:
+			m = v
+		case []interface{}:
+			t, ok := interface{}(nil), false
+			if len(v) > 0 {
+				t, ok = v[len(v)-1].(map[string]interface{})
+			}
+			if !ok || !p.arrays[strings.Join(keys[:i+1], "\x00")] {
+				return nil, p.errorf("key '%s' is not a table", strings.Join(keys[:i+1], "."))
+			}
+			m = t.(map[string]interface{})
+		default:
+			return nil, p.errorf("key '%s' is not a table", strings.Join(keys[:i+1], "."))
+		}
+	}
+	return m, nil
+}
+
+// keyValue parses a "key = value" pair into the table.
+func (p *tomlParser) keyValue(m map[string]interface{}) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after the key")
+	}
+	p.pos++
+
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	t, err := p.subTable(m, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, ok := t[last]; ok {
+		return p.errorf("duplicate key '%s'", strings.Join(keys, "."))
+	}
+	t[last] = v
+	return nil
+}
+
+// key parses a bare, quoted or dotted key.
+func (p *tomlParser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var k string
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		case c == '\'':
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		default:
+			start := p.pos
+			for p.pos < len(p.s) && isTOMLBareKeyChar(p.s[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected a key")
+			}
+			k = p.s[start:p.pos]
+		}
+		keys = append(keys, k)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value parses a value.
+func (p *tomlParser) value() (interface{}, error) {
+	p.skipSpace()
+	switch c := p.peek(); c {
+	case '"':
+		if strings.HasPrefix(p.s[p.pos:], `"""`) {
+			return p.multiLineString('"')
+		}
+		return p.basicString()
+	case '\'':
+		if strings.HasPrefix(p.s[p.pos:], `'''`) {
+			return p.multiLineString('\'')
+		}
+		return p.literalString()
+	case '[':
+		return p.array()
+	case '{':
+		return p.inlineTable()
+	case 0, '\n', '#':
+		return nil, p.errorf("missing value")
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && isTOMLValueChar(p.s[p.pos]) {
+		p.pos++
+	}
+	// Date times may separate the date and time by a space.
+	if tok := p.s[start:p.pos]; len(tok) == 10 && p.peek() == ' ' &&
+		p.pos+3 < len(p.s) && p.s[p.pos+3] == ':' && tomlDateTimeRegexp.MatchString(tok) {
+		p.pos++
+		for p.pos < len(p.s) && isTOMLValueChar(p.s[p.pos]) {
+			p.pos++
+		}
+	}
+	tok := p.s[start:p.pos]
+
+	switch {
+	case tok == "true":
+		return true, nil
+	case tok == "false":
+		return false, nil
+	case tomlDateTimeRegexp.MatchString(tok), tomlTimeRegexp.MatchString(tok):
+		return tok, nil
+	case tomlIntRegexp.MatchString(tok):
+		return json.Number(strings.TrimPrefix(strings.ReplaceAll(tok, "_", ""), "+")), nil
+	case tomlPrefixedRegexp.MatchString(tok):
+		n, err := strconv.ParseInt(tok, 0, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer '%s'", tok)
+		}
+		return json.Number(strconv.FormatInt(n, 10)), nil
+	case tomlFloatRegexp.MatchString(tok):
+		return json.Number(strings.TrimPrefix(strings.ReplaceAll(tok, "_", ""), "+")), nil
+	case strings.HasSuffix(tok, "inf") || strings.HasSuffix(tok, "nan"):
+		return nil, p.errorf("infinite and NaN floats are not supported")
+	}
+	return nil, p.errorf("invalid value '%s'", tok)
+}
+
+func isTOMLValueChar(c byte) bool {
+	return isTOMLBareKeyChar(c) || c == '.' || c == ':' || c == '+'
+}
+
+// array parses an array, which may span several lines.
+func (p *tomlParser) array() (interface{}, error) {
+	p.pos++
+	arr := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// inlineTable parses an inline table, which must be on a single line.
+func (p *tomlParser) inlineTable() (interface{}, error) {
+	p.pos++
+	m := make(map[string]interface{})
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return m, nil
+	}
+	for {
+		err := p.keyValue(m)
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return m, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// literalString parses a single-line literal string.
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.s[p.pos:], "'\n")
+	if end < 0 || p.s[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated literal string")
+	}
+	s := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// basicString parses a single-line basic string.
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for {
+		switch c := p.peek(); c {
+		case 0, '\n':
+			return "", p.errorf("unterminated string")
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			err := p.escape(&b)
+			if err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// multiLineString parses a multi-line basic or literal string, which is
+// delimited by three quotes of q.
+func (p *tomlParser) multiLineString(q byte) (string, error) {
+	p.pos += 3
+	// A line break after the opening delimiter is trimmed.
+	if p.peek() == '\n' {
+		p.pos++
+	}
+
+	delim := strings.Repeat(string(q), 3)
+	var b strings.Builder
+	for {
+		if p.pos == len(p.s) {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if strings.HasPrefix(p.s[p.pos:], delim) {
+			// Up to two quotes may precede the closing delimiter.
+			n := 3
+			for n < 5 && p.pos+n < len(p.s) && p.s[p.pos+n] == q {
+				n++
+			}
+			b.WriteString(p.s[p.pos : p.pos+n-3])
+			p.pos += n
+			return b.String(), nil
+		}
+
+		c := p.peek()
+		if q == '"' && c == '\\' {
+			// A line ending backslash trims the following white space.
+			rest := strings.TrimLeft(p.s[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") {
+				p.pos = len(p.s) - len(strings.TrimLeft(rest, " \t\n"))
+				continue
+			}
+			err := p.escape(&b)
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+}
+
+// escape writes the escape sequence of a basic string at the current position.
+func (p *tomlParser) escape(b *strings.Builder) error {
+	p.pos++
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.s) {
+			return p.errorf("invalid escape sequence")
+		}
+		r, err := strconv.ParseUint(p.s[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid escape sequence '\\%c%s'", c, p.s[p.pos:p.pos+n])
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return p.errorf("invalid escape sequence '\\%c'", c)
+	}
+	return nil
+}
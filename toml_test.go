@@ -0,0 +1,97 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	type m = map[string]interface{}
+	type s = []interface{}
+
+	tests := []struct {
+		doc  string
+		want map[string]interface{}
+	}{
+		{"", m{}},
+		{"# comment\na = 1\nb = \"text\" # trailing\nc = true\n", m{"a": json.Number("1"), "b": "text", "c": true}},
+		{"n = +1_000\nh = 0xff\no = 0o17\nb = 0b101\nf = -1.5e3\ng = 3.0", m{"n": json.Number("1000"), "h": json.Number("255"), "o": json.Number("15"), "b": json.Number("5"), "f": json.Number("-1.5e3"), "g": json.Number("3.0")}},
+		{"d = 1979-05-27\ndt = 1979-05-27 07:32:00Z\nt = 07:32:00", m{"d": "1979-05-27", "dt": "1979-05-27 07:32:00Z", "t": "07:32:00"}},
+		{`a = "tab\t \"q\" \u00e4"` + "\nb = 'C:\\path'\n\"quoted key\" = 1", m{"a": "tab\t \"q\" ä", "b": `C:\path`, "quoted key": json.Number("1")}},
+		{"a = \"\"\"\nline 1\nline 2\\\n   continued\"\"\"\nb = '''\nraw \\n'''", m{"a": "line 1\nline 2continued", "b": "raw \\n"}},
+		{"client.name = \"ACME\"\nclient . address.city = \"Berlin\"", m{"client": m{"name": "ACME", "address": m{"city": "Berlin"}}}},
+		{"[client]\nname = \"ACME\"\n\n[client.address]\ncity = \"Berlin\"", m{"client": m{"name": "ACME", "address": m{"city": "Berlin"}}}},
+		{"[[items]]\nname = \"A\"\n[items.unit]\nv = 1\n[[items]]\nname = \"B\"\n[items.unit]\nv = 2", m{"items": s{m{"name": "A", "unit": m{"v": json.Number("1")}}, m{"name": "B", "unit": m{"v": json.Number("2")}}}}},
+		{"list = [\n  1, # one\n  \"two\",\n  [3],\n]\nmap = { k = \"v\", n.x = 2 }", m{"list": s{json.Number("1"), "two", s{json.Number("3")}}, "map": m{"k": "v", "n": m{"x": json.Number("2")}}}},
+	}
+	for _, tt := range tests {
+		got, err := decodeTOML([]byte(tt.doc))
+		if err != nil {
+			t.Errorf("%q: %v", tt.doc, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %#v, want %#v", tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeTOMLErrors(t *testing.T) {
+	docs := []string{
+		"a = 1\na = 2",
+		"[a]\n[a]",
+		"a = 1\n[a]",
+		"a = 1 b = 2",
+		"a =",
+		"a = [1, 2",
+		"a = \"open",
+		"a = inf",
+		"a = 1__0",
+		"a = 'x'\n[[a]]",
+	}
+	for _, doc := range docs {
+		if v, err := decodeTOML([]byte(doc)); err == nil {
+			t.Errorf("%q: got %#v, want an error", doc, v)
+		}
+	}
+}
+
+func TestFormFromYAMLAndTOML(t *testing.T) {
+	want := Form{"Client.Name": "ACME", "Items.0.Qty": json.Number("2")}
+
+	form, err := FormFromYAML(strings.NewReader("Client:\n  Name: ACME\nItems:\n  - Qty: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(form, want) {
+		t.Errorf("YAML: got %#v, want %#v", form, want)
+	}
+
+	form, err = FormFromTOML(strings.NewReader("Client.Name = \"ACME\"\n[[Items]]\nQty = 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(form, want) {
+		t.Errorf("TOML: got %#v, want %#v", form, want)
+	}
+
+	if _, err = FormFromYAML(strings.NewReader("- a\n- b")); err == nil {
+		t.Error("YAML sequence: want an error")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"strings"
+	"sync"
+)
+
+// transliterations are the Latin-1 replacements of characters without
+// a canonical decomposition and of typographic punctuation.
+var transliterations = map[rune]string{
+	'Đ': "D", 'đ': "d", 'Ħ': "H", 'ħ': "h", 'ı': "i", 'Ĳ': "IJ", 'ĳ': "ij",
+	'ĸ': "k", 'Ŀ': "L", 'ŀ': "l", 'Ł': "L", 'ł': "l", 'ŉ': "'n", 'Ŋ': "N",
+	'ŋ': "n", 'Œ': "OE", 'œ': "oe", 'Ŧ': "T", 'ŧ': "t", 'ſ': "s", 'ƒ': "f",
+	'Ə': "E", 'ə': "e", 'ẞ': "SS",
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-",
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'",
+	'“': "\"", '”': "\"", '„': "\"", '‟': "\"", '″': "\"",
+	'‹': "<", '›': ">", '•': "*", '…': "...", '€': "EUR", '™': "TM",
+}
+
+var (
+	decompositionsOnce sync.Once
+
+	// decompositions are the base characters of the precomposed
+	// characters of the compositions table.
+	decompositions map[rune]rune
+)
+
+// Transliterate replaces characters outside of Latin-1, which the standard
+// fonts of most forms lack, by their closest Latin-1 equivalent, like
+// "Ł" by "L" and "ő" by "o", so they don't render as boxes. Characters
+// without an equivalent, like Cyrillic letters, are kept.
+// Transliterated values can't be restored, so use it as a fallback for
+// forms which must print on fonts without the glyphs.
+func Transliterate(name, value string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(value))
+	for _, r := range value {
+		if s, ok := transliterate(r); ok {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+// transliterate returns the Latin-1 replacement of the character
+// and whether one exists.
+func transliterate(r rune) (string, bool) {
+	if r < 256 {
+		return string(r), true
+	}
+	if s, ok := transliterations[r]; ok {
+		return s, true
+	}
+
+	decompositionsOnce.Do(func() {
+		decompositions = make(map[rune]rune)
+		for _, pairs := range compositions {
+			runes := []rune(pairs)
+			for i := 0; i+1 < len(runes); i += 2 {
+				decompositions[runes[i+1]] = runes[i]
+			}
+		}
+	})
+
+	// Strip the combining marks until a Latin-1 character remains,
+	// like "ộ" to "ọ" to "o".
+	for depth := 0; depth < 4; depth++ {
+		base, ok := decompositions[r]
+		if !ok {
+			break
+		}
+		if base < 256 {
+			return string(base), true
+		}
+		r = base
+	}
+	if s, ok := transliterations[r]; ok {
+		return s, true
+	}
+	return "", false
+}
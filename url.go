@@ -0,0 +1,85 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxRemoteTemplateSize limits the size of templates loaded from URLs.
+const maxRemoteTemplateSize = 64 * 1024 * 1024
+
+// downloadTimeout limits downloads of templates if the context has no deadline.
+const downloadTimeout = time.Minute
+
+// FillFromURL downloads the form PDF file from the HTTP(S) URL and fills
+// it with the form values and options. The download is kept in memory and
+// is limited to 64 MiB and, if the context has no deadline, to one minute.
+// Set the TemplateSHA256 option to verify the checksum of the download.
+func FillFromURL(ctx context.Context, url string, form Form, opts Options) (result io.Reader, err error) {
+	return DefaultFiller.FillFromURL(ctx, url, form, opts)
+}
+
+// FillFromURL downloads the form PDF file from the URL and fills it.
+// See the package-level FillFromURL.
+func (f *Filler) FillFromURL(ctx context.Context, url string, form Form, opts Options) (result io.Reader, err error) {
+	data, err := downloadTemplate(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.fillReader(form, bytes.NewReader(data), opts, nil)
+}
+
+// downloadTemplate downloads the form PDF file from the URL.
+func downloadTemplate(ctx context.Context, url string) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, downloadTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the template: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download the template: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteTemplateSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the template: %v", err)
+	} else if len(data) > maxRemoteTemplateSize {
+		return nil, fmt.Errorf("failed to download the template: exceeds %d bytes", maxRemoteTemplateSize)
+	}
+	return data, nil
+}
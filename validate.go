@@ -0,0 +1,86 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldError describes an invalid form value.
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field '%s': %s", e.Field, e.Msg)
+}
+
+// ValidationError contains all invalid form values.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "invalid form values: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the form values against the form fields of the template.
+// A ValidationError is returned if values are invalid.
+func (t *Template) Validate(form Form) error {
+	fields, err := t.Fields()
+	if err != nil {
+		return err
+	}
+	return validateForm(form, fields)
+}
+
+// validateForm checks the form values against the fields.
+func validateForm(form Form, fields []Field) error {
+	var errs ValidationError
+
+	for _, f := range fields {
+		v, ok := form[f.Name]
+		if !ok || v == nil {
+			continue
+		}
+		value := formatValue(v)
+
+		// Comb fields print one character per box.
+		if f.IsComb() && value != "" && len([]rune(value)) != f.MaxLength {
+			errs = append(errs, FieldError{
+				Field: f.Name,
+				Msg:   fmt.Sprintf("comb field requires %d characters: got %d", f.MaxLength, len([]rune(value))),
+			})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Field < errs[j].Field
+	})
+	return errs
+}
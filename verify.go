@@ -0,0 +1,89 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"io"
+	"sort"
+)
+
+// Mismatch describes a form value which differs from the field value
+// of a PDF file.
+type Mismatch struct {
+	Field    string
+	Expected string
+	Actual   string
+
+	// Missing is set if the PDF file has no field with the name.
+	Missing bool
+}
+
+// Verify re-extracts the field values of the PDF file and compares them
+// with the form values, to confirm a document was filled with the form.
+// The mismatches are sorted by the field name. Flattened documents have
+// no fields left, so every form value is reported as missing.
+func Verify(pdfFile io.Reader, form Form) (bool, []Mismatch, error) {
+	return DefaultFiller.Verify(pdfFile, form)
+}
+
+// Verify re-extracts the field values of the PDF file and compares them
+// with the form values. See the package-level Verify.
+func (f *Filler) Verify(pdfFile io.Reader, form Form) (bool, []Mismatch, error) {
+	fields, err := f.ReadFieldsFromReader(pdfFile)
+	if err != nil {
+		return false, nil, err
+	}
+
+	mismatches := compareFields(fields, form)
+	return len(mismatches) == 0, mismatches, nil
+}
+
+// compareFields returns the form values which differ from the field
+// values, sorted by the field name.
+func compareFields(fields []Field, form Form) []Mismatch {
+	byName := make(map[string]Field, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+
+	var mismatches []Mismatch
+	for k, v := range form {
+		expected := formatValue(v)
+
+		field, ok := byName[k]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Field: k, Expected: expected, Missing: true})
+			continue
+		}
+
+		actual := field.Value
+		if actual == "" && field.Type == FieldTypeButton {
+			// Unchecked buttons have no value.
+			actual = "Off"
+		}
+		if actual != expected {
+			mismatches = append(mismatches, Mismatch{Field: k, Expected: expected, Actual: field.Value})
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Field < mismatches[j].Field
+	})
+	return mismatches
+}
@@ -0,0 +1,183 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// langRegexp matches language tags, like "en" or "en-US".
+var langRegexp = regexp.MustCompile(`^[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// Duplex is the paper handling for duplex printing.
+type Duplex string
+
+// Duplex modes.
+const (
+	Simplex             Duplex = "Simplex"
+	DuplexFlipShortEdge Duplex = "DuplexFlipShortEdge"
+	DuplexFlipLongEdge  Duplex = "DuplexFlipLongEdge"
+)
+
+// ViewerPreferences are print hints for PDF viewers stored in the filled PDF file.
+type ViewerPreferences struct {
+	// Duplex preselects the duplex mode of the print dialog.
+	Duplex Duplex
+
+	// PickTrayByPDFSize selects the paper tray by the page size.
+	PickTrayByPDFSize bool
+
+	// NoPrintScaling disables the page scaling of the print dialog,
+	// so forms are printed at their actual size.
+	NoPrintScaling bool
+
+	// NumCopies preselects the number of copies of the print dialog.
+	NumCopies int
+}
+
+// catalogEntries returns the catalog entries of the preferences.
+func (v ViewerPreferences) catalogEntries() [][2]string {
+	var prefs []string
+	if v.Duplex != "" {
+		prefs = append(prefs, "/Duplex /"+string(v.Duplex))
+	}
+	if v.PickTrayByPDFSize {
+		prefs = append(prefs, "/PickTrayByPDFSize true")
+	}
+	if v.NoPrintScaling {
+		prefs = append(prefs, "/PrintScaling /None")
+	}
+	if v.NumCopies > 0 {
+		prefs = append(prefs, fmt.Sprintf("/NumCopies %d", v.NumCopies))
+	}
+	if len(prefs) == 0 {
+		return nil
+	}
+	return [][2]string{{"/ViewerPreferences", "<< " + strings.Join(prefs, " ") + " >>"}}
+}
+
+// Zoom is the magnification of the initial view.
+type Zoom string
+
+// Zoom modes.
+const (
+	FitPage  Zoom = "Fit"
+	FitWidth Zoom = "FitH"
+)
+
+// PageLayout is the page layout of the initial view.
+type PageLayout string
+
+// Page layouts.
+const (
+	SinglePage     PageLayout = "SinglePage"
+	OneColumn      PageLayout = "OneColumn"
+	TwoColumnLeft  PageLayout = "TwoColumnLeft"
+	TwoColumnRight PageLayout = "TwoColumnRight"
+	TwoPageLeft    PageLayout = "TwoPageLeft"
+	TwoPageRight   PageLayout = "TwoPageRight"
+)
+
+// PageMode selects the panel shown next to the pages of the initial view.
+type PageMode string
+
+// Page modes.
+const (
+	UseNone        PageMode = "UseNone"
+	UseOutlines    PageMode = "UseOutlines"
+	UseThumbs      PageMode = "UseThumbs"
+	FullScreen     PageMode = "FullScreen"
+	UseAttachments PageMode = "UseAttachments"
+)
+
+// InitialView is the view of the filled PDF file when it is opened.
+// Empty values keep the viewer defaults.
+type InitialView struct {
+	// Page is the one-based number of the page to open.
+	Page int
+
+	// Zoom is the magnification of the page.
+	Zoom Zoom
+
+	// PageLayout arranges the pages.
+	PageLayout PageLayout
+
+	// PageMode selects the panel shown next to the pages,
+	// like the bookmarks of an assembled document.
+	PageMode PageMode
+}
+
+// catalogEntries returns the catalog entries of the initial view.
+func (v InitialView) catalogEntries(pdfFile []byte, catalog [][2]string) ([][2]string, error) {
+	var entries [][2]string
+	if v.PageLayout != "" {
+		entries = append(entries, [2]string{"/PageLayout", "/" + string(v.PageLayout)})
+	}
+	if v.PageMode != "" {
+		entries = append(entries, [2]string{"/PageMode", "/" + string(v.PageMode)})
+	}
+
+	if v.Page > 0 || v.Zoom != "" {
+		page := v.Page
+		if page == 0 {
+			page = 1
+		}
+		ref, err := findPage(pdfFile, catalog, page)
+		if err != nil {
+			return nil, fmt.Errorf("initial page %d: %v", page, err)
+		}
+
+		dest := "/XYZ null null null"
+		switch v.Zoom {
+		case FitPage:
+			dest = "/Fit"
+		case FitWidth:
+			dest = "/FitH null"
+		}
+		entries = append(entries, [2]string{"/OpenAction", "[" + ref + " " + dest + "]"})
+	}
+	return entries, nil
+}
+
+// catalogEntries returns the catalog entries of the viewer options
+// and the document language.
+func (o Options) catalogEntries(pdfFile []byte, catalog [][2]string) ([][2]string, error) {
+	var entries [][2]string
+	if o.Lang != "" {
+		// Language tags consist of letters, digits and hyphens,
+		// so they need no escaping.
+		if !langRegexp.MatchString(o.Lang) {
+			return nil, fmt.Errorf("invalid language tag: '%s'", o.Lang)
+		}
+		entries = append(entries, [2]string{"/Lang", "(" + o.Lang + ")"})
+	}
+	if o.ViewerPreferences != nil {
+		entries = append(entries, o.ViewerPreferences.catalogEntries()...)
+	}
+	if o.InitialView != nil {
+		e, err := o.InitialView.catalogEntries(pdfFile, catalog)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e...)
+	}
+	return entries, nil
+}
@@ -0,0 +1,118 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Watch polls the directory in the interval and reloads the templates
+// which have been added, modified or removed, until the context is done.
+// Files are named like for LoadDir. The templates are registered as
+// snapshots of the file data, which are analyzed before they replace the
+// registered versions, so broken files do not replace working templates.
+// Failed reloads are retried in the next interval. Reload errors are
+// passed to onError, which may be nil. The initial load is done by Watch
+// as well.
+func (r *Registry) Watch(ctx context.Context, dir string, interval time.Duration, onError func(error)) {
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	type fileState struct {
+		modTime time.Time
+		size    int64
+	}
+	states := make(map[TemplateRef]fileState)
+
+	reload := func() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			onError(err)
+			return
+		}
+
+		seen := make(map[TemplateRef]bool)
+		for _, e := range entries {
+			ref, ok := templateFileRef(e.Name())
+			if !ok || e.IsDir() {
+				continue
+			}
+			seen[ref] = true
+
+			info, err := e.Info()
+			if err != nil {
+				onError(err)
+				continue
+			}
+
+			state := fileState{modTime: info.ModTime(), size: info.Size()}
+			if old, ok := states[ref]; ok && old == state {
+				continue
+			}
+
+			// The registered template is a snapshot of the analyzed
+			// data, so later writes to the file don't affect it.
+			data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			if err != nil {
+				onError(fmt.Errorf("failed to reload template '%s': %v", ref, err))
+				continue
+			}
+			t := r.filler.NewTemplateFromBytes(data)
+			_, err = t.Fields()
+			if err != nil {
+				onError(fmt.Errorf("failed to reload template '%s': %v", ref, err))
+				continue
+			}
+
+			err = r.Register(ref.Name, ref.Version, t)
+			if err != nil {
+				onError(err)
+				continue
+			}
+			// Failed reloads are retried by the next poll.
+			states[ref] = state
+		}
+
+		for ref := range states {
+			if !seen[ref] {
+				delete(states, ref)
+				r.Unregister(ref.Name, ref.Version)
+			}
+		}
+	}
+
+	reload()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reload()
+		}
+	}
+}
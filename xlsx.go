@@ -0,0 +1,352 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSXOptions are the options of FormsFromXLSX.
+type XLSXOptions struct {
+	// Sheet is the name of the sheet. Defaults to the first sheet.
+	Sheet string
+
+	// Mapping maps header names to field names. Columns without a mapping
+	// are skipped. The header names are used as field names if it is nil.
+	Mapping map[string]string
+
+	// Coerce fills numeric cells as numbers and boolean cells as check box
+	// states, instead of their text.
+	Coerce bool
+
+	// DateColumns are the header names of columns with dates, which Excel
+	// stores as serial numbers. They are filled as "2006-01-02".
+	DateColumns []string
+}
+
+// FormsFromXLSX creates a form from every row of an Excel spreadsheet
+// after the header row, which is the first row of the sheet.
+// Empty rows are skipped.
+func FormsFromXLSX(r io.ReaderAt, size int64, opts XLSXOptions) ([]Form, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %v", err)
+	}
+
+	sheetPath, err := xlsxSheetPath(z, opts.Sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []string
+	if f := xlsxFile(z, "xl/sharedStrings.xml"); f != nil {
+		shared, err = xlsxSharedStrings(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f := xlsxFile(z, sheetPath)
+	if f == nil {
+		return nil, fmt.Errorf("invalid XLSX file: missing '%s'", sheetPath)
+	}
+	rows, err := xlsxRows(f, shared)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	dates := make(map[string]bool, len(opts.DateColumns))
+	for _, c := range opts.DateColumns {
+		dates[c] = true
+	}
+
+	var forms []Form
+	for n, row := range rows[1:] {
+		form := make(Form, len(header))
+		for i, c := range row {
+			if i >= len(header) || header[i].text == "" || c.text == "" {
+				continue
+			}
+
+			column := header[i].text
+			name := column
+			if opts.Mapping != nil {
+				var ok bool
+				name, ok = opts.Mapping[column]
+				if !ok {
+					continue
+				}
+			}
+
+			value, err := c.value(opts.Coerce, dates[column])
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column '%s': %v", n+2, column, err)
+			}
+			form[name] = value
+		}
+		if len(form) > 0 {
+			forms = append(forms, form)
+		}
+	}
+	return forms, nil
+}
+
+// xlsxCell is a cell value with its type.
+type xlsxCell struct {
+	typ  string
+	text string
+}
+
+// value returns the form value of the cell.
+func (c xlsxCell) value(coerce, date bool) (interface{}, error) {
+	if date && (c.typ == "" || c.typ == "n") {
+		serial, err := strconv.ParseFloat(c.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: '%s'", c.text)
+		}
+		// Excel counts the days since 1899-12-30.
+		t := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).Add(time.Duration(serial * float64(24*time.Hour)))
+		return t.Format(exprDateLayout), nil
+	}
+
+	if !coerce {
+		return c.text, nil
+	}
+
+	switch c.typ {
+	case "", "n":
+		f, err := strconv.ParseFloat(c.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: '%s'", c.text)
+		}
+		return f, nil
+	case "b":
+		return c.text == "1", nil
+	default:
+		return c.text, nil
+	}
+}
+
+// xlsxFile returns the file of the archive with the name.
+func xlsxFile(z *zip.Reader, name string) *zip.File {
+	for _, f := range z.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// maxXLSXFileSize limits the inflated size of the XML files of an XLSX
+// file, which protects against decompression bombs in uploaded files.
+const maxXLSXFileSize = 64 * 1024 * 1024
+
+// maxXLSXColumns is the number of columns of a worksheet, up to "XFD".
+const maxXLSXColumns = 16384
+
+// decodeXLSXFile decodes the XML file of the archive.
+func decodeXLSXFile(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	lr := &io.LimitedReader{R: rc, N: maxXLSXFileSize + 1}
+	err = xml.NewDecoder(lr).Decode(v)
+	if lr.N <= 0 {
+		return fmt.Errorf("invalid XLSX file: '%s' exceeds %d bytes", f.Name, maxXLSXFileSize)
+	} else if err != nil {
+		return fmt.Errorf("invalid XLSX file: '%s': %v", f.Name, err)
+	}
+	return nil
+}
+
+// xlsxSheetPath returns the path of the worksheet with the name
+// or of the first worksheet.
+func xlsxSheetPath(z *zip.Reader, name string) (string, error) {
+	f := xlsxFile(z, "xl/workbook.xml")
+	if f == nil {
+		return "", fmt.Errorf("invalid XLSX file: missing workbook")
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	err := decodeXLSXFile(f, &workbook)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	for _, s := range workbook.Sheets {
+		if name == "" || s.Name == name {
+			id = s.ID
+			break
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("sheet does not exist: '%s'", name)
+	}
+
+	f = xlsxFile(z, "xl/_rels/workbook.xml.rels")
+	if f == nil {
+		return "", fmt.Errorf("invalid XLSX file: missing workbook relationships")
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	err = decodeXLSXFile(f, &rels)
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range rels.Relationships {
+		if r.ID != id {
+			continue
+		}
+		if strings.HasPrefix(r.Target, "/") {
+			return strings.TrimPrefix(r.Target, "/"), nil
+		}
+		return path.Join("xl", r.Target), nil
+	}
+	return "", fmt.Errorf("invalid XLSX file: missing relationship '%s'", id)
+}
+
+// xlsxText is a shared or inline string, which consists of runs if it is formatted.
+type xlsxText struct {
+	T    string   `xml:"t"`
+	Runs []string `xml:"r>t"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) > 0 {
+		return strings.Join(t.Runs, "")
+	}
+	return t.T
+}
+
+// xlsxSharedStrings returns the shared strings of the workbook.
+func xlsxSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	err := decodeXLSXFile(f, &sst)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		shared[i] = si.String()
+	}
+	return shared, nil
+}
+
+// xlsxRows returns the cells of the rows of the worksheet by column index.
+func xlsxRows(f *zip.File, shared []string) ([][]xlsxCell, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	err := decodeXLSXFile(f, &sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]xlsxCell, len(sheet.Rows))
+	for i, r := range sheet.Rows {
+		var row []xlsxCell
+		for j, c := range r.Cells {
+			// Empty cells may be omitted.
+			col := j
+			if c.Ref != "" {
+				col = xlsxColumn(c.Ref)
+			}
+			if col < 0 {
+				return nil, fmt.Errorf("invalid XLSX file: invalid cell reference '%s'", c.Ref)
+			} else if col < len(row) {
+				col = len(row)
+			}
+			for len(row) < col {
+				row = append(row, xlsxCell{})
+			}
+
+			cell := xlsxCell{typ: c.Type, text: c.Value}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, fmt.Errorf("invalid XLSX file: invalid shared string '%s'", c.Value)
+				}
+				cell.text = shared[n]
+			case "inlineStr":
+				cell.text = c.Inline.String()
+			}
+			row = append(row, cell)
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// xlsxColumn returns the zero based column index of a cell reference like "AB12".
+// References beyond the last column "XFD" are invalid and return -1.
+func xlsxColumn(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		if col > maxXLSXColumns {
+			return -1
+		}
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
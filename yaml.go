@@ -0,0 +1,648 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yamlNumberRegexp matches the numbers of the YAML core schema.
+var yamlNumberRegexp = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+
+// jsonNumberRegexp matches numbers in JSON syntax.
+var jsonNumberRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// decodeYAML decodes a YAML document into maps, slices, strings,
+// json.Number numbers, booleans and nil values, like a JSON document
+// decoded with UseNumber.
+//
+// Only the block and flow styles used by configuration files are
+// supported: mappings, sequences, plain, quoted and block scalars and
+// single-line flow collections. Anchors, aliases, tags, multiple
+// documents and multi-line plain scalars fail with an error.
+func decodeYAML(data []byte) (interface{}, error) {
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	p := &yamlParser{lines: strings.Split(strings.TrimSuffix(text, "\n"), "\n")}
+	p.skipDirectives()
+
+	var v interface{}
+	if p.next() {
+		var err error
+		v, err = p.block(p.indent())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.pos < len(p.lines) {
+		if strings.TrimRight(p.lines[p.pos], " ") == "..." {
+			p.pos++
+		}
+		if p.next() || p.pos < len(p.lines) {
+			return nil, p.errorf("multiple documents are not supported")
+		}
+	}
+	return v, nil
+}
+
+// yamlParser parses the lines of a YAML document.
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+// errorf returns an error at the current line.
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// skipDirectives skips the directives and the start marker of the document.
+func (p *yamlParser) skipDirectives() {
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := strings.TrimSpace(p.lines[p.pos])
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "%") {
+			continue
+		}
+		if line == "---" || strings.HasPrefix(line, "--- ") {
+			// Content after the marker is parsed like a line of its own.
+			p.lines[p.pos] = strings.TrimSpace(strings.TrimPrefix(line, "---"))
+			if p.lines[p.pos] == "" {
+				p.pos++
+			}
+		}
+		return
+	}
+}
+
+// next skips blank and comment lines and returns whether a line of the
+// document follows. Document markers end the document.
+func (p *yamlParser) next() bool {
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if line == "..." || line == "---" || strings.HasPrefix(line, "... ") || strings.HasPrefix(line, "--- ") {
+			return false
+		}
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
+
+// indent returns the indentation of the current line.
+func (p *yamlParser) indent() int {
+	line := p.lines[p.pos]
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// content returns the current line without the indentation.
+func (p *yamlParser) content() (string, error) {
+	line := p.lines[p.pos][p.indent():]
+	if strings.HasPrefix(line, "\t") {
+		return "", p.errorf("tabs are not allowed as indentation")
+	}
+	return strings.TrimRight(line, " \t"), nil
+}
+
+// isSeqItem returns whether the line content is a sequence item.
+func isSeqItem(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// block parses the block node at the current line with the indentation.
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	s, err := p.content()
+	if err != nil {
+		return nil, err
+	}
+
+	if isSeqItem(s) {
+		return p.sequence(indent)
+	}
+	if _, _, ok, err := p.splitKey(s); err != nil {
+		return nil, err
+	} else if ok {
+		return p.mapping(indent)
+	}
+
+	v, err := p.inline(s, indent)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// mapping parses a block mapping with the indentation.
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.next() {
+		n := p.indent()
+		if n < indent {
+			break
+		} else if n > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		s, err := p.content()
+		if err != nil {
+			return nil, err
+		} else if isSeqItem(s) {
+			break
+		}
+
+		key, rest, ok, err := p.splitKey(s)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, p.errorf("expected a mapping key: '%s'", s)
+		} else if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key '%s'", key)
+		}
+
+		v, err := p.value(rest, indent, true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// sequence parses a block sequence with the indentation.
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+	seq := []interface{}{}
+	for p.next() {
+		n := p.indent()
+		if n < indent {
+			break
+		} else if n > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		s, err := p.content()
+		if err != nil {
+			return nil, err
+		} else if !isSeqItem(s) {
+			break
+		}
+
+		rest := strings.TrimLeft(s[1:], " ")
+		col := indent + len(s) - len(rest)
+
+		// Compact nested collections start on the line of the item.
+		_, _, isKey, err := p.splitKey(rest)
+		if err != nil {
+			return nil, err
+		}
+		if isKey || isSeqItem(rest) {
+			p.lines[p.pos] = strings.Repeat(" ", col) + rest
+			v, err := p.block(col)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+			continue
+		}
+
+		v, err := p.value(rest, indent, false)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+	}
+	return seq, nil
+}
+
+// value parses the value following a mapping key or a sequence item
+// of the current line. The value is either inline or a nested block.
+// Sequences of mapping values may have the indentation of the key.
+func (p *yamlParser) value(rest string, indent int, inMapping bool) (interface{}, error) {
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "#") {
+		rest = ""
+	}
+
+	if rest != "" {
+		if rest[0] == '|' || rest[0] == '>' {
+			return p.blockScalar(rest, indent)
+		}
+		v, err := p.inline(rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	p.pos++
+	if !p.next() {
+		return nil, nil
+	}
+	n := p.indent()
+	if n > indent {
+		return p.block(n)
+	}
+	if s, err := p.content(); err != nil {
+		return nil, err
+	} else if n == indent && inMapping && isSeqItem(s) {
+		return p.sequence(n)
+	}
+	return nil, nil
+}
+
+// inline parses a scalar or flow collection, which ends the current line.
+func (p *yamlParser) inline(s string, indent int) (interface{}, error) {
+	v, rest, err := p.flowValue(s, false)
+	if err != nil {
+		return nil, err
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return nil, p.errorf("unexpected '%s' after the value", rest)
+	}
+	p.pos++
+
+	// Plain scalars continued on the next lines are not supported.
+	if p.next() && p.indent() > indent {
+		if s, err := p.content(); err == nil && !isSeqItem(s) {
+			if _, _, ok, _ := p.splitKey(s); !ok {
+				return nil, p.errorf("multi-line plain scalars are not supported")
+			}
+		}
+	}
+	return v, nil
+}
+
+// blockScalar parses a literal (|) or folded (>) block scalar whose
+// header is on the current line.
+func (p *yamlParser) blockScalar(header string, indent int) (interface{}, error) {
+	if i := strings.Index(header, " #"); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.TrimSpace(header)
+
+	folded := header[0] == '>'
+	chomp := byte(0)
+	blockIndent := 0
+	for _, c := range []byte(header[1:]) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && blockIndent == 0:
+			blockIndent = indent + int(c-'0')
+		default:
+			return nil, p.errorf("invalid block scalar header '%s'", header)
+		}
+	}
+	p.pos++
+
+	var lines []string
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent == 0 {
+			if n <= indent {
+				break
+			}
+			blockIndent = n
+		}
+		if n < blockIndent {
+			break
+		}
+		lines = append(lines, line[blockIndent:])
+	}
+
+	// Trailing empty lines are handled by the chomping.
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if folded {
+		text = foldYAMLLines(lines)
+	} else {
+		text = strings.Join(lines, "\n")
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	switch chomp {
+	case '-':
+		return text, nil
+	case '+':
+		return text + "\n" + strings.Repeat("\n", trailing), nil
+	default:
+		return text + "\n", nil
+	}
+}
+
+// foldYAMLLines joins the lines of a folded block scalar. Line breaks
+// between text lines become spaces, empty lines become line breaks and
+// more indented lines keep their line breaks.
+func foldYAMLLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case line == "":
+				b.WriteByte('\n')
+			case prev == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(prev, " "):
+				if prev != "" {
+					b.WriteByte('\n')
+				}
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// splitKey splits a "key: value" line content. ok is false if the
+// content is not a mapping entry.
+func (p *yamlParser) splitKey(s string) (key, rest string, ok bool, err error) {
+	if s == "" || s[0] == '[' || s[0] == '{' || isSeqItem(s) {
+		return "", "", false, nil
+	}
+
+	if s[0] == '"' || s[0] == '\'' {
+		k, after, err := p.quoted(s)
+		if err != nil {
+			return "", "", false, err
+		}
+		after = strings.TrimLeft(after, " ")
+		if after == ":" || strings.HasPrefix(after, ": ") {
+			return k, after[1:], true, nil
+		}
+		return "", "", false, nil
+	}
+
+	if strings.HasPrefix(s, "? ") {
+		return "", "", false, p.errorf("complex mapping keys are not supported")
+	}
+	i := strings.Index(s, ": ")
+	if i < 0 && strings.HasSuffix(s, ":") {
+		i = len(s) - 1
+	}
+	if i <= 0 || strings.Contains(s[:i], " #") {
+		return "", "", false, nil
+	}
+	return strings.TrimRight(s[:i], " "), s[i+1:], true, nil
+}
+
+// flowValue parses a scalar or flow collection at the start of s and
+// returns the remaining text. In flow collections, plain scalars end
+// at ',', ']', '}' and ": ".
+func (p *yamlParser) flowValue(s string, inFlow bool) (interface{}, string, error) {
+	s = strings.TrimLeft(s, " ")
+	if s == "" {
+		return nil, "", nil
+	}
+
+	switch s[0] {
+	case '"', '\'':
+		return p.quoted(s)
+	case '[':
+		return p.flowSequence(s[1:])
+	case '{':
+		return p.flowMapping(s[1:])
+	case '&', '*', '!':
+		return nil, "", p.errorf("anchors, aliases and tags are not supported")
+	case '|', '>':
+		if !inFlow {
+			return nil, "", p.errorf("unexpected block scalar")
+		}
+	case '@', '`':
+		return nil, "", p.errorf("reserved character '%c'", s[0])
+	}
+
+	end := len(s)
+	if i := strings.Index(s, " #"); i >= 0 {
+		end = i
+	}
+	if inFlow {
+		for i := 0; i < end; i++ {
+			c := s[i]
+			if c == ',' || c == ']' || c == '}' || (c == ':' && (i+1 == len(s) || strings.ContainsRune(" ,]}", rune(s[i+1])))) {
+				end = i
+				break
+			}
+		}
+	}
+	return resolveYAMLScalar(strings.TrimSpace(s[:end])), s[end:], nil
+}
+
+// flowSequence parses a flow sequence after the opening bracket.
+func (p *yamlParser) flowSequence(s string) (interface{}, string, error) {
+	seq := []interface{}{}
+	for {
+		s = strings.TrimLeft(s, " ")
+		if strings.HasPrefix(s, "]") {
+			return seq, s[1:], nil
+		} else if s == "" || s[0] == '#' {
+			return nil, "", p.errorf("unterminated flow sequence (multi-line flow collections are not supported)")
+		}
+
+		v, rest, err := p.flowValue(s, true)
+		if err != nil {
+			return nil, "", err
+		}
+		seq = append(seq, v)
+
+		s = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, "", p.errorf("expected ',' or ']' in flow sequence")
+		}
+	}
+}
+
+// flowMapping parses a flow mapping after the opening brace.
+func (p *yamlParser) flowMapping(s string) (interface{}, string, error) {
+	m := make(map[string]interface{})
+	for {
+		s = strings.TrimLeft(s, " ")
+		if strings.HasPrefix(s, "}") {
+			return m, s[1:], nil
+		} else if s == "" || s[0] == '#' {
+			return nil, "", p.errorf("unterminated flow mapping (multi-line flow collections are not supported)")
+		}
+
+		k, rest, err := p.flowValue(s, true)
+		if err != nil {
+			return nil, "", err
+		}
+		switch k.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, "", p.errorf("complex mapping keys are not supported")
+		}
+		key := formatValue(k)
+		if k == nil {
+			key = ""
+		}
+		if _, dup := m[key]; dup {
+			return nil, "", p.errorf("duplicate key '%s'", key)
+		}
+
+		var v interface{}
+		s = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(s, ":") {
+			v, rest, err = p.flowValue(s[1:], true)
+			if err != nil {
+				return nil, "", err
+			}
+			s = strings.TrimLeft(rest, " ")
+		}
+		m[key] = v
+
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			return nil, "", p.errorf("expected ',' or '}' in flow mapping")
+		}
+	}
+}
+
+// quoted parses a single or double quoted scalar at the start of s and
+// returns the remaining text.
+func (p *yamlParser) quoted(s string) (string, string, error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '\'' && s[i] == '\'':
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.ReplaceAll(s[1:i], "''", "'"), s[i+1:], nil
+
+		case q == '"' && s[i] == '\\':
+			i++
+
+		case q == '"' && s[i] == '"':
+			v, err := unquoteYAML(s[1:i])
+			if err != nil {
+				return "", "", p.errorf("invalid double quoted scalar: %v", err)
+			}
+			return v, s[i+1:], nil
+		}
+	}
+	return "", "", p.errorf("unterminated quoted scalar (multi-line quoted scalars are not supported)")
+}
+
+// unquoteYAML resolves the escape sequences of a double quoted scalar.
+func unquoteYAML(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+
+		switch s[i] {
+		case '0':
+			b.WriteByte(0)
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 't', '\t':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'v':
+			b.WriteByte('\v')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case 'e':
+			b.WriteByte(0x1b)
+		case ' ', '"', '/', '\\':
+			b.WriteByte(s[i])
+		case 'N':
+			b.WriteRune('\u0085')
+		case '_':
+			b.WriteRune('\u00A0')
+		case 'L':
+			b.WriteRune('\u2028')
+		case 'P':
+			b.WriteRune('\u2029')
+		case 'x', 'u', 'U':
+			n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+			if i+n >= len(s) {
+				return "", fmt.Errorf("invalid escape sequence '\\%s'", s[i:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence '\\%s'", s[i:i+1+n])
+			}
+			b.WriteRune(rune(r))
+			i += n
+		default:
+			return "", fmt.Errorf("invalid escape sequence '\\%c'", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// resolveYAMLScalar returns the value of a plain scalar of the YAML core
+// schema. Numbers are returned as json.Number in JSON syntax.
+func resolveYAMLScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if !yamlNumberRegexp.MatchString(s) {
+		return s
+	}
+	if n := strings.TrimPrefix(s, "+"); jsonNumberRegexp.MatchString(n) {
+		return json.Number(n)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
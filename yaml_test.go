@@ -0,0 +1,80 @@
+/*
+ *  FillPDF - Fill PDF forms
+ *  Copyright DesertBit
+ *  Author: Roland Singer
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package fillpdf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeYAML(t *testing.T) {
+	type m = map[string]interface{}
+	type s = []interface{}
+
+	tests := []struct {
+		doc  string
+		want interface{}
+	}{
+		{"", nil},
+		{"a: 1\nb: text\nc: true\nd: ~\ne:\n", m{"a": json.Number("1"), "b": "text", "c": true, "d": nil, "e": nil}},
+		{"---\n# comment\nname: John Doe # trailing\n...\n", m{"name": "John Doe"}},
+		{"n: +1.50\nx: .5\ny: 1e3\nz: 0123", m{"n": json.Number("1.50"), "x": json.Number("0.5"), "y": json.Number("1e3"), "z": json.Number("123")}},
+		{`q: "a \"b\" \u00e4\n"` + "\nr: 'it''s # no comment'", m{"q": "a \"b\" ä\n", "r": "it's # no comment"}},
+		{"url: http://example.com/a#b\ntime: 12:30", m{"url": "http://example.com/a#b", "time": "12:30"}},
+		{"client:\n  name: ACME\n  address:\n    city: Berlin\n", m{"client": m{"name": "ACME", "address": m{"city": "Berlin"}}}},
+		{"items:\n- a\n- b\nnext: 1", m{"items": s{"a", "b"}, "next": json.Number("1")}},
+		{"items:\n  - name: A\n    qty: 2\n  - name: B\n", m{"items": s{m{"name": "A", "qty": json.Number("2")}, m{"name": "B"}}}},
+		{"- - 1\n  - 2\n- 3\n", s{s{json.Number("1"), json.Number("2")}, json.Number("3")}},
+		{"list: [a, 'b, c', 1, [x]]\nmap: {k: v, n: 2, e: }", m{"list": s{"a", "b, c", json.Number("1"), s{"x"}}, "map": m{"k": "v", "n": json.Number("2"), "e": nil}}},
+		{"text: |\n  line 1\n   line 2\n\n  line 3\n\nnext: x", m{"text": "line 1\n line 2\n\nline 3\n", "next": "x"}},
+		{"text: >-\n  folded\n  line\n\n  para\n", m{"text": "folded line\npara"}},
+		{"text: |+\n  keep\n\n", m{"text": "keep\n\n"}},
+		{"\"a: b\": 1\n'c': 2", m{"a: b": json.Number("1"), "c": json.Number("2")}},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		got, err := decodeYAML([]byte(tt.doc))
+		if err != nil {
+			t.Errorf("%q: %v", tt.doc, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %#v, want %#v", tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeYAMLErrors(t *testing.T) {
+	docs := []string{
+		"a: 1\na: 2",
+		"a: &x 1\nb: *x",
+		"a: !!str 1",
+		"a: 1\n---\nb: 2",
+		"a:\n\tb: 1",
+		"a: [1, 2",
+		"a: \"open",
+		"a: multi\n  line",
+		"a: 1\n  b: 2",
+		"? complex\n: key",
+	}
+	for _, doc := range docs {
+		if v, err := decodeYAML([]byte(doc)); err == nil {
+			t.Errorf("%q: got %#v, want an error", doc, v)
+		}
+	}
+}